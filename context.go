@@ -0,0 +1,142 @@
+// Package wwplugin 请求上下文
+// 将调用请求的元数据注入函数上下文，便于函数读取请求ID、调用来源等信息
+package wwplugin
+
+import (
+	"context"       // 上下文控制，用于携带请求元数据
+	"encoding/json" // JSON处理，用于编码调用链路
+	"strconv"       // 字符串转换，用于解析派发时间戳
+	"strings"       // 字符串处理，用于解析旧格式的调用链路
+	"time"          // 时间处理，用于计算调用耗时
+)
+
+// 框架管理的元数据键
+const (
+	metadataDispatchTime = "dispatch_time_ns" // 主机派发调用的时间 - 纳秒级Unix时间戳
+	metadataCallPath     = "call_path"        // 插件间调用链路 - JSON数组编码的插件ID，按调用顺序排列
+	metadataCallToken    = "call_token"       // 插件间调用凭证 - 主机注册插件时签发，用于确认调用方身份
+)
+
+// 插件间调用响应的元数据键
+const (
+	MetadataServingPluginID   = "serving_plugin_id"   // 实际处理调用的插件ID
+	MetadataServingPluginName = "serving_plugin_name" // 实际处理调用的插件名称
+)
+
+// requestContextKey 请求上下文键类型 - 避免与其他包的上下文键冲突
+type requestContextKey int
+
+// 请求上下文键常量定义
+const (
+	requestIDKey       requestContextKey = iota // 请求ID
+	requestMetadataKey                          // 请求元数据
+	callReceivedKey                             // 插件收到调用的时间
+	functionNameKey                             // 被调用的函数名称
+	hostKey                                     // 处理调用的插件主机
+)
+
+// withRequest 将请求ID和元数据注入上下文
+func withRequest(ctx context.Context, requestID string, metadata map[string]string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return context.WithValue(ctx, requestMetadataKey, metadata)
+}
+
+// RequestMetadataFromContext 获取当前调用请求的元数据
+// 常见键: source（host/inter_plugin）、source_plugin、timestamp；返回副本，修改不影响原请求
+func RequestMetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(requestMetadataKey).(map[string]string)
+	result := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		result[key] = value
+	}
+	return result
+}
+
+// withFunctionName 将被调用的函数名称注入上下文
+func withFunctionName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, functionNameKey, name)
+}
+
+// FunctionNameFromContext 获取当前调用请求的函数名称
+// 主要供 Plugin.SetDefaultHandler 设置的默认处理器按名称动态分发，不在调用上下文中时返回空字符串
+func FunctionNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(functionNameKey).(string)
+	return name
+}
+
+// withHost 将插件主机注入上下文
+func withHost(ctx context.Context, host *PluginHost) context.Context {
+	return context.WithValue(ctx, hostKey, host)
+}
+
+// HostFromContext 获取处理当前调用的插件主机
+// 在主机函数中可用于列出插件或调用其他插件，无需在闭包中捕获主机；不在主机函数调用上下文中时返回nil
+func HostFromContext(ctx context.Context) *PluginHost {
+	host, _ := ctx.Value(hostKey).(*PluginHost)
+	return host
+}
+
+// withCallReceived 将插件收到调用的时间注入上下文
+func withCallReceived(ctx context.Context, received time.Time) context.Context {
+	return context.WithValue(ctx, callReceivedKey, received)
+}
+
+// DispatchTimeFromContext 获取主机派发当前调用的时间
+// 主机未写入派发时间（如旧版本主机）时 ok 为 false
+func DispatchTimeFromContext(ctx context.Context) (dispatched time.Time, ok bool) {
+	metadata, _ := ctx.Value(requestMetadataKey).(map[string]string)
+	nanos, err := strconv.ParseInt(metadata[metadataDispatchTime], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// DeliveryLatencyFromContext 获取调用从主机派发到插件收到的耗时
+// 主机与插件运行在同一台机器上，共用系统时钟；无法获取派发时间时返回0
+func DeliveryLatencyFromContext(ctx context.Context) time.Duration {
+	dispatched, ok := DispatchTimeFromContext(ctx)
+	received, _ := ctx.Value(callReceivedKey).(time.Time)
+	if !ok || received.IsZero() || received.Before(dispatched) {
+		return 0
+	}
+	return received.Sub(dispatched)
+}
+
+// CallPathFromContext 获取当前插件间调用的链路（按调用顺序的插件ID）
+// 不是插件间调用时返回nil
+func CallPathFromContext(ctx context.Context) []string {
+	metadata, _ := ctx.Value(requestMetadataKey).(map[string]string)
+	return splitCallPath(metadata[metadataCallPath])
+}
+
+// joinCallPath 编码调用链路元数据
+// 使用JSON数组编码，插件ID中包含任意字符（如逗号）时也能正确还原
+func joinCallPath(path []string) string {
+	data, err := json.Marshal(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// splitCallPath 解析调用链路元数据
+// 兼容旧版本主机写入的逗号分隔格式
+func splitCallPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	if strings.HasPrefix(path, "[") {
+		var ids []string
+		if err := json.Unmarshal([]byte(path), &ids); err == nil {
+			return ids
+		}
+	}
+	return strings.Split(path, ",")
+}
+
+// RequestIDFromContext 获取当前调用请求的ID，不在调用上下文中时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
@@ -0,0 +1,32 @@
+package wwplugin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestCallPathRoundTrip 调用链路编码后能还原包含逗号的插件ID
+func TestCallPathRoundTrip(t *testing.T) {
+	path := []string{"plugin-a", "plugin,b", "plugin-c"}
+
+	encoded := joinCallPath(path)
+	if got := splitCallPath(encoded); !reflect.DeepEqual(got, path) {
+		t.Fatalf("调用链路还原错误: 期望 %v，实际 %v（编码 %q）", path, got, encoded)
+	}
+
+	ctx := withRequest(context.Background(), "req-1", map[string]string{metadataCallPath: encoded})
+	if got := CallPathFromContext(ctx); !reflect.DeepEqual(got, path) {
+		t.Fatalf("CallPathFromContext 错误: 期望 %v，实际 %v", path, got)
+	}
+}
+
+// TestCallPathLegacyFormat 兼容旧版本主机写入的逗号分隔调用链路
+func TestCallPathLegacyFormat(t *testing.T) {
+	if got := splitCallPath("plugin-a,plugin-b"); !reflect.DeepEqual(got, []string{"plugin-a", "plugin-b"}) {
+		t.Fatalf("旧格式调用链路解析错误: %v", got)
+	}
+	if got := splitCallPath(""); got != nil {
+		t.Fatalf("空调用链路应返回nil，实际 %v", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package wwplugin 主机地址发现
+// 主机将实际监听地址写入发现文件，插件重连时读取，主机换端口重启后插件无需重启即可重新连接
+package wwplugin
+
+import (
+	"encoding/json" // JSON处理，用于读写插件列表文件
+	"fmt"           // 格式化输出，用于生成地址和错误信息
+	"os"            // 文件操作，用于读写发现文件
+	"path/filepath" // 路径处理，用于在同一目录下创建临时文件
+	"strings"       // 字符串处理，用于去除地址两端的空白
+)
+
+// envHostDiscoveryFile 主机启动插件时传递发现文件路径的环境变量
+const envHostDiscoveryFile = "HOST_DISCOVERY_FILE"
+
+// writeDiscoveryFile 将主机的实际监听地址写入发现文件
+// 先写临时文件再重命名，插件不会读到写了一半的地址
+func (ph *PluginHost) writeDiscoveryFile() error {
+	if ph.actualPort == 0 {
+		return fmt.Errorf("主机未监听TCP端口")
+	}
+
+	path := ph.config.DiscoveryFile
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建发现文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := fmt.Fprintf(tmp, "localhost:%d\n", ph.actualPort); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入发现文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入发现文件失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入发现文件失败: %v", err)
+	}
+	return nil
+}
+
+// knownPluginsFilePath 插件列表文件路径 - 位于发现文件旁，记录主机已注册的插件ID
+func knownPluginsFilePath(discoveryFile string) string {
+	return discoveryFile + ".plugins"
+}
+
+// loadKnownPlugins 读取重启前记录的插件ID，作为本次可接管的插件
+// 文件不存在或格式错误时不接管任何插件
+func (ph *PluginHost) loadKnownPlugins() {
+	path := knownPluginsFilePath(ph.config.DiscoveryFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ph.logger.Warn("⚠️ 读取插件列表文件失败", "path", path, "error", err)
+		}
+		return
+	}
+
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		ph.logger.Warn("⚠️ 解析插件列表文件失败", "path", path, "error", err)
+		return
+	}
+
+	ph.adoptMutex.Lock()
+	defer ph.adoptMutex.Unlock()
+	ph.adoptablePlugins = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		ph.adoptablePlugins[id] = true
+	}
+}
+
+// takeAdoptablePlugin 检查插件ID是否可接管，可接管时将其移除，同一ID不会被接管两次
+func (ph *PluginHost) takeAdoptablePlugin(pluginID string) bool {
+	ph.adoptMutex.Lock()
+	defer ph.adoptMutex.Unlock()
+	if !ph.adoptablePlugins[pluginID] {
+		return false
+	}
+	delete(ph.adoptablePlugins, pluginID)
+	return true
+}
+
+// writeKnownPlugins 将已注册的插件ID和尚未重连的可接管插件ID写入插件列表文件
+// 与发现文件一样先写临时文件再重命名；临时文件权限为0600，其他用户无法读取插件ID
+func (ph *PluginHost) writeKnownPlugins() error {
+	ph.adoptMutex.Lock()
+	defer ph.adoptMutex.Unlock()
+
+	ids := make([]string, 0, ph.registry.Count()+len(ph.adoptablePlugins))
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		if !plugin.InProcess {
+			ids = append(ids, plugin.ID)
+		}
+		return true
+	})
+	for id := range ph.adoptablePlugins {
+		ids = append(ids, id)
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("序列化插件列表失败: %v", err)
+	}
+
+	path := knownPluginsFilePath(ph.config.DiscoveryFile)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建插件列表文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入插件列表文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入插件列表文件失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入插件列表文件失败: %v", err)
+	}
+	return nil
+}
+
+// discoveryFilePath 传给插件的发现文件路径 - 转为绝对路径，插件的工作目录可能与主机不同
+func (ph *PluginHost) discoveryFilePath() string {
+	path := ph.config.DiscoveryFile
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// refreshHostAddress 从发现文件读取主机地址，地址变化时更新 HostAddress
+// 未配置发现文件或读取失败时保持原地址
+func (p *Plugin) refreshHostAddress() {
+	path := p.config.HostDiscoveryFile
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		p.logger.Debug("读取主机发现文件失败", "path", path, "error", err)
+		return
+	}
+
+	address := strings.TrimSpace(string(data))
+	if address == "" || address == p.config.HostAddress {
+		return
+	}
+	p.logger.Info("🔎 主机地址已变化", "old_address", p.config.HostAddress, "address", address)
+	p.config.HostAddress = address
+}
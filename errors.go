@@ -0,0 +1,42 @@
+// Package wwplugin 错误定义
+// 集中定义框架对外暴露的错误值与错误码，便于调用方使用 errors.Is 判断
+package wwplugin
+
+import (
+	"errors" // 错误处理，用于定义哨兵错误
+)
+
+// 调用响应错误码常量定义
+const (
+	ErrorCodeMarshal       = "MARSHAL_ERROR"  // 返回值序列化失败
+	ErrorCodePluginStopped = "PLUGIN_STOPPED" // 目标插件在调用过程中被停止
+	ErrorCodeFunctionPanic = "FUNCTION_PANIC" // 函数执行过程中发生panic
+
+	ErrorCodeParamTooLarge = "PARAM_TOO_LARGE" // 参数或返回值超过 HostConfig.MaxParamBytes
+)
+
+// logCategoryPanic 插件上报panic时使用的日志分类
+const logCategoryPanic = "panic"
+
+// 框架错误定义
+var (
+	ErrMarshal          = errors.New("返回值序列化失败")    // 函数返回值无法序列化
+	ErrPluginStopped    = errors.New("插件已停止")       // 插件在调用过程中被停止，进行中的调用被取消
+	ErrRegisterRejected = errors.New("主机拒绝注册")      // 主机明确拒绝插件注册，重试无意义
+	ErrFunctionPanic    = errors.New("函数执行发生panic") // 函数panic已被框架恢复，调用以失败返回
+	ErrShutdownVetoed   = errors.New("插件拒绝关闭")      // 插件的关闭处理器拒绝了关闭请求，插件继续运行
+	ErrHostDisconnected = errors.New("主机连接断开")      // 插件与主机的连接持续中断，按配置关闭插件
+	ErrHostNotConnected = errors.New("未连接到指定主机")    // 调用的主机名称未通过 ConnectHost 连接
+	ErrFunctionNotFound = errors.New("插件未提供该函数")    // 插件的函数列表中没有该函数，调用未发出
+	ErrPluginDisabled   = errors.New("插件已禁用")       // 插件被 DisablePlugin 禁用，调用被拒绝
+	ErrParamTooLarge    = errors.New("参数超过大小限制")    // 参数或返回值编码后超过 HostConfig.MaxParamBytes
+	ErrStopTimeout      = errors.New("插件未在截止时间内停止") // 插件在停止截止时间内未退出，已被强制终止
+
+	ErrExecutableNotFound   = errors.New("插件可执行文件不存在")  // 启动插件时找不到可执行文件
+	ErrExecutablePermission = errors.New("插件可执行文件无法执行") // 启动插件时没有执行权限
+	ErrCrashOnStart         = errors.New("插件启动后立即退出")   // 插件进程在注册到主机前退出，记录在 PluginInfo.LastError
+
+	ErrAlreadyStarted = errors.New("重复启动") // 主机或插件的 Start 被重复调用，已启动（或已停止）的实例不能再次启动
+
+	ErrPluginAdopted = errors.New("插件由主机接管，不是本主机启动的进程") // 主机重启后接管的插件没有可执行文件，不能启动、重启或升级
+)
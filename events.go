@@ -0,0 +1,88 @@
+// Package wwplugin 插件生命周期事件
+// 提供主机侧的插件事件定义与订阅机制，便于应用感知插件状态变化
+package wwplugin
+
+import (
+	"sync" // 同步原语，保护事件处理器列表
+	"time" // 时间处理，记录事件发生时间
+)
+
+// PluginEventType 插件生命周期事件类型
+type PluginEventType string
+
+// 插件生命周期事件常量定义
+const (
+	EventPluginGaveUp PluginEventType = "plugin_gave_up" // 插件重启次数耗尽，放弃重启 - 需要人工介入
+	EventPluginPanic  PluginEventType = "plugin_panic"   // 插件函数发生panic - Message 包含函数名、请求ID和堆栈
+
+	EventPluginCrashed       PluginEventType = "plugin_crashed"        // 插件进程异常退出 - Message 包含退出错误和stderr最后输出
+	EventPluginStatusChanged PluginEventType = "plugin_status_changed" // 插件状态变化 - Message 为 "旧状态 -> 新状态"，每次转换只发布一次
+	EventPluginReady         PluginEventType = "plugin_ready"          // 插件已连接并进入运行状态 - 包括自动重启后重新就绪
+	EventPluginHealthChanged PluginEventType = "plugin_health_changed" // 插件心跳上报的健康状态变化 - Message 为 "旧状态 -> 新状态"
+
+	EventHostServeFailed PluginEventType = "host_serve_failed" // 主机gRPC服务意外退出 - 主机级事件，PluginID 为空、Plugin 为nil，Message 为退出错误
+)
+
+// PluginEvent 插件生命周期事件
+type PluginEvent struct {
+	Type     PluginEventType `json:"type"`      // 事件类型
+	PluginID string          `json:"plugin_id"` // 插件ID
+	Plugin   *PluginInfo     `json:"-"`         // 插件信息 - 事件发生时的插件对象
+	Message  string          `json:"message"`   // 事件描述
+	Time     time.Time       `json:"time"`      // 事件发生时间
+}
+
+// PluginEventHandler 插件事件处理器类型定义
+type PluginEventHandler func(event PluginEvent)
+
+// eventBus 事件分发器
+// 按注册顺序同步调用所有处理器
+type eventBus struct {
+	handlers []PluginEventHandler
+	mutex    sync.RWMutex
+}
+
+// subscribe 注册事件处理器
+func (eb *eventBus) subscribe(handler PluginEventHandler) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+	eb.handlers = append(eb.handlers, handler)
+}
+
+// emit 分发事件到所有处理器
+func (eb *eventBus) emit(event PluginEvent) {
+	eb.mutex.RLock()
+	handlers := make([]PluginEventHandler, len(eb.handlers))
+	copy(handlers, eb.handlers)
+	eb.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// OnPluginEvent 订阅所有插件生命周期事件
+func (ph *PluginHost) OnPluginEvent(handler PluginEventHandler) {
+	ph.events.subscribe(handler)
+}
+
+// OnPluginGaveUp 订阅插件放弃重启事件
+// 插件重启次数耗尽后回调，应用可借此通知运维人员
+func (ph *PluginHost) OnPluginGaveUp(handler func(plugin *PluginInfo)) {
+	ph.events.subscribe(func(event PluginEvent) {
+		if event.Type == EventPluginGaveUp {
+			handler(event.Plugin)
+		}
+	})
+}
+
+// emitEvent 发布插件事件
+func (ph *PluginHost) emitEvent(eventType PluginEventType, plugin *PluginInfo, message string) {
+	ph.events.emit(PluginEvent{
+		Type:     eventType,
+		PluginID: plugin.ID,
+		Plugin:   plugin,
+		Message:  message,
+		Time:     time.Now(),
+	})
+}
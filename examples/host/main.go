@@ -3,8 +3,10 @@
 package main
 
 import (
-	"log"  // 日志记录，用于输出运行信息和错误
-	"time" // 时间处理，用于延时和定时操作
+	"log"           // 日志记录，用于输出运行信息和错误
+	"os"            // 操作系统接口，用于读取命令行参数
+	"path/filepath" // 路径处理，用于拼接平台相关的插件路径
+	"time"          // 时间处理，用于延时和定时操作
 
 	wwplugin "github.com/wwwlkj/wwhyplugin" // WWPlugin插件框架核心库
 	"github.com/wwwlkj/wwhyplugin/proto"    // gRPC协议定义，用于参数传递
@@ -39,8 +41,11 @@ func main() {
 	go func() {
 		time.Sleep(2 * time.Second)
 
-		// 尝试加载示例插件
-		pluginPath := `D:\GoSrc\wwplugin\examples\sample_plugin\plugin.exe`
+		// 尝试加载示例插件，可通过第一个命令行参数指定插件路径
+		pluginPath := filepath.Join("examples", "sample_plugin", wwplugin.ExecutableName("plugin"))
+		if len(os.Args) > 1 {
+			pluginPath = os.Args[1]
+		}
 		plugin, err := host.StartPluginByPath(pluginPath)
 		if err != nil {
 			log.Printf("自动加载插件失败: %v", err)
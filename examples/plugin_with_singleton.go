@@ -369,23 +369,8 @@ func showPluginStatus() {
 
 // getPluginConfigFunction 获取插件配置的函数（可被主机调用）
 func getPluginConfigFunction(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
-	// 将配置转换为JSON格式返回
-	configJSON := "{"
-	i := 0
-	for key, value := range globalConfig {
-		if i > 0 {
-			configJSON += ","
-		}
-		configJSON += fmt.Sprintf(`"%s":"%s"`, key, value)
-		i++
-	}
-	configJSON += "}"
-
-	return &proto.Parameter{
-		Name:  "plugin_config",
-		Type:  proto.ParameterType_JSON,
-		Value: configJSON,
-	}, nil
+	// 将配置转换为JSON格式返回（由框架负责转义，值中包含引号也能正确处理）
+	return wwplugin.NewJSONParameter("plugin_config", globalConfig)
 }
 
 // updatePluginConfigFunction 更新插件配置的函数（可被主机调用）
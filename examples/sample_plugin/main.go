@@ -15,30 +15,14 @@ import (
 )
 
 // main 主函数 - 插件程序入口点
-// 处理命令行参数，支持--info查询模式和正常运行模式
+// 支持--info查询模式和正常运行模式，由 RunPluginMain 统一处理
 func main() {
-	// 检查命令行参数，支持信息查询模式
-	// --info 参数用于获取插件元数据，而不启动服务
-	if len(os.Args) > 1 && os.Args[1] == "--info" {
-		// 信息查询模式：不启动服务，只输出插件信息
-		// 主机可以使用此功能在不加载插件的情况下获取插件信息
-		plugin := createSamplePlugin() // 创建插件实例但不启动服务
-		if err := plugin.StartWithInfo(); err != nil {
-			os.Exit(1) // 信息查询失败则退出
-		}
-		os.Exit(0) // 正常退出信息查询模式
-	}
-
-	// 输出启动信息
-	log.Println("启动示例插件...")
-
 	// 创建插件实例
 	// 这将配置插件的基本信息和能力
 	plugin := createSamplePlugin()
 
-	// 启动插件
-	// 这将启动gRPC服务器、连接主机并注册服务
-	if err := plugin.Start(); err != nil {
+	// 带 --info 参数时只输出插件信息，否则启动gRPC服务器、连接主机并注册服务
+	if err := wwplugin.RunPluginMain(plugin, os.Args); err != nil {
 		log.Fatalf("启动插件失败: %v", err) // 启动失败则退出
 	}
 }
@@ -146,7 +130,12 @@ func testHostCall(plugin *wwplugin.Plugin) wwplugin.PluginFunction {
 			return nil, fmt.Errorf("主机函数调用失败: %s", resp.Message)
 		}
 
-		result := fmt.Sprintf("主机时间: %s", resp.Result.Value)
+		hostTime, err := resp.Result.AsTime()
+		if err != nil {
+			return nil, fmt.Errorf("解析主机时间失败: %v", err)
+		}
+
+		result := fmt.Sprintf("主机时间: %s", hostTime.Format("2006-01-02 15:04:05"))
 
 		return &proto.Parameter{
 			Name:  "host_call_result",
@@ -173,7 +162,7 @@ func testPluginCall(plugin *wwplugin.Plugin) wwplugin.PluginFunction {
 		}
 
 		// 调用其他插件的函数
-		resp, err := plugin.CallOtherPlugin(targetPluginID, functionName, callParams)
+		resp, err := plugin.CallOtherPluginCtx(ctx, targetPluginID, functionName, callParams)
 		if err != nil {
 			return nil, fmt.Errorf("调用插件函数失败: %v", err)
 		}
@@ -13,24 +13,11 @@ import (
 
 // main 主函数 - 插件程序入口点
 func main() {
-	// 检查命令行参数，支持信息查询模式
-	if len(os.Args) > 1 && os.Args[1] == "--info" {
-		// 信息查询模式：不启动服务，只输出插件信息
-		plugin := createSamplePluginWithLogo()
-		if err := plugin.StartWithInfo(); err != nil {
-			os.Exit(1)
-		}
-		os.Exit(0)
-	}
-
-	// 输出启动信息
-	log.Println("启动带Logo的示例插件...")
-
 	// 创建插件实例
 	plugin := createSamplePluginWithLogo()
 
-	// 启动插件
-	if err := plugin.Start(); err != nil {
+	// 带 --info 参数时只输出插件信息，否则启动插件
+	if err := wwplugin.RunPluginMain(plugin, os.Args); err != nil {
 		log.Fatalf("启动插件失败: %v", err)
 	}
 }
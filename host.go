@@ -5,18 +5,27 @@ package wwplugin
 import (
 	"context"       // 上下文控制，用于取消和超时管理
 	"encoding/json" // JSON编解码，用于配置和数据交换
+	"errors"        // 错误处理，用于包装插件拒绝关闭的原因
 	"fmt"           // 格式化输出，用于错误信息和日志
-	"log"           // 日志记录，用于运行时信息输出
+	"io/fs"         // 文件系统错误，用于区分插件启动失败的原因
 	"net"           // 网络操作，gRPC服务器监听
 	"os"            // 操作系统接口，环境变量和信号处理
 	"os/exec"       // 进程执行，用于启动插件进程
 	"os/signal"     // 系统信号处理，用于优雅关闭
+	"path/filepath" // 文件路径处理，用于定位插件清单
+	"runtime"       // 运行时信息，用于获取操作系统类型
+	"sort"          // 排序，用于稳定输出插件列表
+	"strings"       // 字符串处理，用于版本号解析
 	"sync"          // 同步原语，管理并发访问
+	"sync/atomic"   // 原子操作，用于调用计数
 	"syscall"       // 系统调用，用于信号处理
 	"time"          // 时间处理，心跳和超时管理
 
-	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
-	"google.golang.org/grpc"             // gRPC框架
+	"github.com/wwwlkj/wwhyplugin/proto"          // gRPC协议定义
+	"google.golang.org/grpc"                      // gRPC框架
+	"google.golang.org/grpc/connectivity"         // gRPC连接状态，用于连接诊断
+	"google.golang.org/grpc/credentials/insecure" // gRPC安全凭据（不加密）
+	"google.golang.org/grpc/reflection"           // gRPC服务反射，用于调试
 )
 
 // PluginHost 插件主机结构体 - 管理插件生命周期和通信
@@ -30,15 +39,44 @@ type PluginHost struct {
 	listener      net.Listener            // 网络监听器 - 监听客户端连接
 	actualPort    int                     // 实际使用端口 - 可能与配置不同（自动分配）
 	hostFunctions map[string]HostFunction // 主机函数映射 - 插件可调用的函数
+	logger        Logger                  // 日志接口 - 来自配置或默认的标准库实现
+	funcMutex     sync.RWMutex            // 主机函数映射读写锁
+
+	streamFunctions map[string]StreamHostFunction // 流式主机函数映射 - 与主机函数共用读写锁
+
+	// === 路由组件 === //
+	routeStrategy RouteStrategy // 能力路由策略 - 多个插件提供同一能力时的选择方式
+	routeMutex    sync.RWMutex  // 路由策略读写锁
+
+	// === 访问控制 === //
+	interPluginPolicy map[string]map[string]bool // 插件间调用策略 - 源插件ID到允许调用的目标插件集合
+	policyMutex       sync.RWMutex               // 调用策略读写锁
+
+	// === 插件接管 === //
+	adoptablePlugins map[string]bool // 可接管的插件ID - 主机启动时从发现文件旁的插件列表读取，接管后移除
+	adoptMutex       sync.Mutex      // 可接管插件ID和插件列表文件互斥锁
+
+	// === 插件信息缓存 === //
+	infoCache pluginInfoCache // --info 查询结果缓存 - 可执行文件修改后失效
 
 	// === 控制组件 === //
-	ctx          context.Context    // 全局上下文 - 用于统一取消操作
-	cancel       context.CancelFunc // 取消函数 - 用于停止所有子操作
-	wg           sync.WaitGroup     // 等待组 - 等待所有goroutine结束
-	shutdownChan chan bool          // 关闭信号通道 - 用于通知主动关闭
+	ctx            context.Context    // 全局上下文 - 用于统一取消操作
+	cancel         context.CancelFunc // 取消函数 - 用于停止所有子操作
+	wg             sync.WaitGroup     // 等待组 - 等待所有goroutine结束
+	shutdownCtx    context.Context    // 关闭请求上下文 - 系统信号、Shutdown、gRPC服务故障等任一触发即取消，Wait 据此返回
+	shutdownCancel context.CancelFunc // 触发关闭请求 - 可重复调用
+	stopOnce       sync.Once          // 保证停止流程只执行一次
+	started        int32              // 启动标志 - 原子操作，防止重复启动
 
 	// === 监控组件 === //
-	heartbeatTicker *time.Ticker // 心跳计时器 - 定期检查插件健康状态
+	heartbeatTicker *time.Ticker  // 心跳计时器 - 定期检查插件健康状态
+	events          eventBus      // 事件分发器 - 通知插件生命周期事件
+	readyCh         chan struct{} // 插件就绪通知 - 任一插件进入运行状态时关闭并替换
+	readyMutex      sync.Mutex    // 就绪通知互斥锁
+	startTime       time.Time     // 主机启动时间 - 用于计算运行时长
+	callsServed     uint64        // 已处理的插件调用总数 - 原子计数
+	pluginPanics    uint64        // 插件上报的函数panic总数 - 原子计数
+	metrics         callMetrics   // 调用指标 - 按调用方向、调用方、被调用方和函数统计
 }
 
 // NewPluginHost 创建新的插件主机实例
@@ -55,47 +93,96 @@ func NewPluginHost(config *HostConfig) (*PluginHost, error) {
 		config = DefaultHostConfig()
 	}
 
+	// 未注入日志实现时使用标准库日志
+	logger := config.Logger
+	if logger == nil {
+		level := parseLogLevel(config.LogLevel)
+		if config.DebugMode {
+			level = DEBUG
+		}
+		logger = NewStdLogger(level)
+	}
+	logger = withFields(logger, "component", "host")
+
 	// 创建可取消的上下文，用于统一控制所有子操作
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// 关闭请求与全局上下文分开：请求关闭后仍需先停止插件，再取消子操作
+	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
+
 	// 初始化主机结构体
 	host := &PluginHost{
 		config:        config,                        // 保存配置信息
 		registry:      NewPluginRegistry(),           // 创建插件注册表
 		hostFunctions: make(map[string]HostFunction), // 初始化主机函数映射
+		logger:        logger,                        // 设置日志接口
+		routeStrategy: RouteFirst,                    // 默认选择第一个可用插件
 		ctx:           ctx,                           // 设置上下文
 		cancel:        cancel,                        // 设置取消函数
-		shutdownChan:  make(chan bool, 1),            // 创建关闭信号通道
+		readyCh:       make(chan struct{}),           // 创建就绪通知通道
+
+		shutdownCtx:    shutdownCtx,    // 设置关闭请求上下文
+		shutdownCancel: shutdownCancel, // 设置关闭请求触发函数
+
+		streamFunctions: make(map[string]StreamHostFunction), // 初始化流式主机函数映射
+
+		interPluginPolicy: make(map[string]map[string]bool), // 初始化插件间调用策略
 	}
 
 	// 创建主机服务实例，用于处理插件请求
 	host.hostService = newHostService(host)
 
 	// 注册默认的主机函数（系统时间、系统信息等）
-	host.registerDefaultFunctions()
+	if !config.DisableDefaultFunctions {
+		host.registerDefaultFunctions()
+	}
 
 	return host, nil // 返回初始化完成的主机
 }
 
 // Start 启动插件主机
+// 每个主机只能启动一次，重复调用返回 ErrAlreadyStarted
 func (ph *PluginHost) Start() error {
-	log.Printf("🚀 启动插件主机...")
+	if !atomic.CompareAndSwapInt32(&ph.started, 0, 1) {
+		return ErrAlreadyStarted
+	}
+
+	ph.logger.Info("🚀 启动插件主机...")
 
-	// 启动gRPC服务器
+	// 启动gRPC服务器，失败时尚未启动任何协程，允许调整配置后重试
 	if err := ph.startGrpcServer(); err != nil {
+		atomic.StoreInt32(&ph.started, 0)
 		return fmt.Errorf("启动gRPC服务器失败: %v", err)
 	}
 
+	// 写入地址发现文件，失败不影响主机运行
+	if ph.config.DiscoveryFile != "" {
+		ph.loadKnownPlugins()
+		if err := ph.writeDiscoveryFile(); err != nil {
+			ph.logger.Warn("⚠️ 写入主机地址发现文件失败", "path", ph.config.DiscoveryFile, "error", err)
+		}
+	}
+
 	// 启动监控
 	ph.startMonitoring()
 
-	log.Printf("✅ 插件主机启动完成，监听端口: %d", ph.actualPort)
+	ph.startTime = time.Now()
+	ph.logger.Info("✅ 插件主机启动完成", "port", ph.actualPort)
 	return nil
 }
 
 // Stop 停止插件主机
+// 可重复调用，只有第一次调用执行停止流程，并发的调用等待其完成后返回
 func (ph *PluginHost) Stop() {
-	log.Printf("🛑 停止插件主机...")
+	ph.stopOnce.Do(ph.stop)
+}
+
+// stop 执行停止流程
+func (ph *PluginHost) stop() {
+	ph.logger.Info("🛑 停止插件主机...")
+
+	// 直接调用 Stop 时同样让 Wait 返回
+	ph.shutdownCancel()
 
 	// 停止所有插件
 	ph.StopAllPlugins()
@@ -105,9 +192,9 @@ func (ph *PluginHost) Stop() {
 		ph.heartbeatTicker.Stop()
 	}
 
-	// 停止gRPC服务器
+	// 停止gRPC服务器（超时后强制关闭）
 	if ph.grpcServer != nil {
-		ph.grpcServer.GracefulStop()
+		stopGrpcServer(ph.grpcServer, ph.config.GracefulStopTimeout, ph.logger)
 	}
 
 	// 关闭监听器
@@ -115,36 +202,65 @@ func (ph *PluginHost) Stop() {
 		ph.listener.Close()
 	}
 
+	// 移除地址发现文件，插件在主机重新启动前保持原地址重试
+	if ph.config.DiscoveryFile != "" {
+		os.Remove(ph.config.DiscoveryFile)
+	}
+
 	// 取消上下文
 	ph.cancel()
 
 	// 等待所有协程结束
 	ph.wg.Wait()
 
-	log.Printf("✅ 插件主机已安全停止")
+	ph.logger.Info("✅ 插件主机已安全停止")
+}
+
+// Shutdown 请求关闭主机，使 Wait 停止主机后返回
+// 不等待停止完成，可在任意协程（包括主机函数、事件回调）中调用，重复调用无副作用
+func (ph *PluginHost) Shutdown() {
+	ph.shutdownCancel()
 }
 
 // Wait 等待退出信号
+// 收到系统信号或关闭请求（Shutdown、gRPC服务故障、Stop）后停止主机并返回
 func (ph *PluginHost) Wait() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
 	select {
 	case <-sigChan:
-		log.Printf("📥 收到系统退出信号...")
-	case <-ph.shutdownChan:
-		log.Printf("📥 收到程序关闭信号...")
+		ph.logger.Info("📥 收到系统退出信号...")
+	case <-ph.shutdownCtx.Done():
+		ph.logger.Info("📥 收到程序关闭信号...")
 	}
 
 	ph.Stop()
 }
 
+// defaultMaxRestarts 未指定重启策略时插件的最大重启次数
+const defaultMaxRestarts = 3
+
 // LoadPlugin 加载插件
 func (ph *PluginHost) LoadPlugin(executablePath string) (*PluginInfo, error) {
-	log.Printf("📦 正在加载插件: %s", executablePath)
+	return ph.LoadPluginWithOptions(executablePath, LoadOptions{})
+}
+
+// LoadPluginWithOptions 按加载选项加载插件
+func (ph *PluginHost) LoadPluginWithOptions(executablePath string, opts LoadOptions) (*PluginInfo, error) {
+	policy := RestartPolicy{AutoRestart: ph.config.AutoRestartPlugin, MaxRestarts: defaultMaxRestarts}
+	if opts.RestartPolicy != nil {
+		if opts.RestartPolicy.MaxRestarts < 0 {
+			return nil, fmt.Errorf("最大重启次数不能为负数: %d", opts.RestartPolicy.MaxRestarts)
+		}
+		policy = *opts.RestartPolicy
+	}
 
-	// 获取插件信息
-	pluginBasicInfo, err := ph.GetPluginInfo(executablePath)
+	ph.logger.Info("📦 正在加载插件", "path", executablePath)
+
+	// 获取插件信息，不使用缓存：未固定ID的插件每次查询生成新ID，同一可执行文件可加载为多个实例
+	pluginBasicInfo, err := ph.RefreshPluginInfo(executablePath)
 	if err != nil {
 		return nil, fmt.Errorf("获取插件信息失败: %v", err)
 	}
@@ -153,7 +269,7 @@ func (ph *PluginHost) LoadPlugin(executablePath string) (*PluginInfo, error) {
 	pluginID := pluginBasicInfo.ID
 	if pluginID == "" {
 		// 如果插件没有固定ID，则生成一个
-		pluginID = fmt.Sprintf("plugin-%d", time.Now().UnixNano())
+		pluginID = "plugin-" + newUUID()
 	}
 
 	pluginInfo := &PluginInfo{
@@ -165,15 +281,17 @@ func (ph *PluginHost) LoadPlugin(executablePath string) (*PluginInfo, error) {
 		Functions:      pluginBasicInfo.Functions,
 		ExecutablePath: executablePath,
 		Status:         StatusStopped,
-		AutoRestart:    ph.config.AutoRestartPlugin,
-		MaxRestarts:    3,
+		AutoRestart:    policy.AutoRestart,
+		MaxRestarts:    policy.MaxRestarts,
 		RestartCount:   0,
+
+		FunctionTimeouts: functionTimeouts(pluginBasicInfo.FunctionTimeouts),
 	}
 
 	// 注册到注册表
 	ph.registry.Register(pluginInfo)
 
-	log.Printf("✅ 插件已加载（ID: %s）", pluginID)
+	ph.logger.Info("✅ 插件已加载", "plugin_id", pluginID)
 	return pluginInfo, nil
 }
 
@@ -188,7 +306,18 @@ func (ph *PluginHost) StartPlugin(pluginID string) error {
 		return fmt.Errorf("插件 %s 已在运行中", pluginID)
 	}
 
-	log.Printf("🚀 正在启动插件: %s", plugin.ExecutablePath)
+	if plugin.Adopted {
+		return fmt.Errorf("%w: %s", ErrPluginAdopted, pluginID)
+	}
+
+	// 进程内插件没有进程，直接恢复运行状态
+	if plugin.InProcess {
+		plugin.resetCallContext(ph.ctx)
+		ph.setPluginStatus(plugin, StatusRunning)
+		return nil
+	}
+
+	ph.logger.Info("🚀 正在启动插件", "plugin_id", plugin.ID, "path", plugin.ExecutablePath)
 	return ph.startPluginProcess(plugin)
 }
 
@@ -224,40 +353,386 @@ func (ph *PluginHost) StopPlugin(pluginID string) error {
 		return fmt.Errorf("插件 %s 不存在", pluginID)
 	}
 
-	log.Printf("🛑 正在停止插件: %s", pluginID)
-	err := ph.stopPluginProcess(plugin)
+	ph.logger.Info("🛑 正在停止插件", "plugin_id", pluginID)
+	err := ph.stopPluginProcess(plugin, proto.ShutdownReason_SHUTDOWN_REASON_OPERATOR)
 	if err == nil {
 		// 停止成功后从注册表中移除插件
 		ph.registry.Unregister(pluginID)
-		log.Printf("✅ 插件已从注册表中移除: %s", pluginID)
+		ph.logger.Info("✅ 插件已从注册表中移除", "plugin_id", pluginID)
 	}
 	return err
 }
 
-// StopAllPlugins 停止所有插件
-func (ph *PluginHost) StopAllPlugins() {
+// StopPluginCtx 优雅停止插件并等待其进程退出，成功返回时插件已完全停止并从注册表中移除
+// ctx 截止时插件进程被强制终止并返回 ErrStopTimeout；插件拒绝关闭时返回 ErrShutdownVetoed
+func (ph *PluginHost) StopPluginCtx(ctx context.Context, pluginID string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	ph.logger.Info("🛑 正在停止插件", "plugin_id", pluginID)
+	return ph.stopPluginCtx(ctx, plugin, proto.ShutdownReason_SHUTDOWN_REASON_OPERATOR)
+}
+
+// StartAllPlugins 启动所有未运行的插件
+// 按关闭优先级从大到小启动，与StopAllPlugins的停止顺序相反
+func (ph *PluginHost) StartAllPlugins() error {
 	plugins := ph.registry.List()
-	var pluginIDs []string
+	sortByShutdownPriority(plugins)
 
-	// 先收集所有需要停止的插件ID
-	for _, plugin := range plugins {
-		if plugin.Status == StatusRunning {
-			pluginIDs = append(pluginIDs, plugin.ID)
+	var firstErr error
+	for i := len(plugins) - 1; i >= 0; i-- {
+		plugin := plugins[i]
+		if plugin.Status != StatusStopped {
+			continue
+		}
+		if err := ph.StartPlugin(plugin.ID); err != nil {
+			ph.logger.Error("启动插件失败", "plugin_id", plugin.ID, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
+	return firstErr
+}
+
+// SetPluginConfig 设置下发给插件的配置
+// 配置在每次连接插件后推送；插件正在运行时立即推送，推送失败时返回错误（配置仍会保存）
+func (ph *PluginHost) SetPluginConfig(pluginID string, config map[string]string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	data := make(map[string]string, len(config))
+	for key, value := range config {
+		data[key] = value
+	}
+	plugin.PluginConfigData = data
+
+	if plugin.Status != StatusRunning {
+		return nil
+	}
+	return ph.pushPluginConfig(plugin)
+}
+
+// pushPluginConfig 向插件推送 PluginConfigData，未设置配置时不推送
+func (ph *PluginHost) pushPluginConfig(plugin *PluginInfo) error {
+	if len(plugin.PluginConfigData) == 0 {
+		return nil
+	}
+	client := plugin.client()
+	if client == nil {
+		return fmt.Errorf("插件 %s gRPC客户端未连接", plugin.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := client.PushConfig(ctx, &proto.ConfigRequest{Config: plugin.PluginConfigData})
+	if err != nil {
+		return fmt.Errorf("推送配置失败: %v", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("插件拒绝配置: %s", resp.Message)
+	}
+	return nil
+}
+
+// ConfigureHeartbeat 调整插件的心跳间隔（秒）
+// 怀疑插件不稳定时可临时缩短间隔加强监测，传0恢复插件自身配置的间隔；
+// 间隔不应超过主机的心跳超时（HeartbeatInterval × MaxHeartbeatMiss），否则插件会被判定为心跳超时
+func (ph *PluginHost) ConfigureHeartbeat(pluginID string, intervalSeconds int) error {
+	if intervalSeconds < 0 {
+		return fmt.Errorf("心跳间隔无效: %d", intervalSeconds)
+	}
+
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+	client := plugin.client()
+	if client == nil {
+		return fmt.Errorf("插件 %s gRPC客户端未连接", pluginID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := client.ConfigureHeartbeat(ctx, &proto.HeartbeatConfigRequest{IntervalSeconds: int32(intervalSeconds)})
+	if err != nil {
+		return fmt.Errorf("调整心跳间隔失败: %v", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("插件拒绝调整心跳间隔: %s", resp.Message)
+	}
+
+	ph.logger.Info("插件心跳间隔已调整", "plugin_id", pluginID, "interval_seconds", resp.IntervalSeconds)
+	return nil
+}
+
+// DisablePlugin 禁用插件
+// 插件进程和连接保持不变，但主机不再向其转发调用和消息；插件进程退出时也不会自动重启
+func (ph *PluginHost) DisablePlugin(pluginID string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	plugin.Disabled = true
+	if plugin.Status == StatusRunning {
+		ph.setPluginStatus(plugin, StatusDisabled)
+	}
+	ph.logger.Info("⏸️ 插件已禁用", "plugin_id", pluginID)
+	return nil
+}
+
+// EnablePlugin 启用被 DisablePlugin 禁用的插件，恢复接收调用
+func (ph *PluginHost) EnablePlugin(pluginID string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	plugin.Disabled = false
+	if plugin.Status == StatusDisabled {
+		ph.setPluginStatus(plugin, StatusRunning)
+	}
+	ph.logger.Info("▶️ 插件已启用", "plugin_id", pluginID)
+	return nil
+}
+
+// SetRestartPolicy 设置插件崩溃后的自动重启策略，覆盖主机的 AutoRestartPlugin 配置
+// 已重启的次数不会清零，超过新的最大重启次数时下次崩溃不再重启
+func (ph *PluginHost) SetRestartPolicy(pluginID string, autoRestart bool, maxRestarts int) error {
+	if maxRestarts < 0 {
+		return fmt.Errorf("最大重启次数不能为负数: %d", maxRestarts)
+	}
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+	if autoRestart && plugin.Adopted {
+		return fmt.Errorf("%w: %s", ErrPluginAdopted, pluginID)
+	}
+	plugin.AutoRestart = autoRestart
+	plugin.MaxRestarts = maxRestarts
+	return nil
+}
+
+// SetShutdownPriority 设置插件关闭优先级
+// 数值越小越先停止；StopAllPlugins按此顺序停止插件
+func (ph *PluginHost) SetShutdownPriority(pluginID string, priority int) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+	plugin.ShutdownPriority = priority
+	return nil
+}
+
+// StopAllPlugins 停止所有插件
+// 按关闭优先级从小到大依次停止，优先级相同时按插件ID排序
+func (ph *PluginHost) StopAllPlugins() {
+	// 先收集所有需要停止的插件，停止过程较慢，不能在遍历注册表时进行
+	var plugins []*PluginInfo
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		if plugin.Status == StatusRunning || plugin.Status == StatusDisabled {
+			plugins = append(plugins, plugin)
+		}
+		return true
+	})
+	sortByShutdownPriority(plugins)
 
 	// 停止所有插件
 	for _, plugin := range plugins {
-		if plugin.Status == StatusRunning {
-			ph.stopPluginProcess(plugin)
-		}
+		ph.stopPluginProcess(plugin, proto.ShutdownReason_SHUTDOWN_REASON_HOST_SHUTDOWN)
 	}
 
 	// 从注册表中移除所有已停止的插件
-	for _, pluginID := range pluginIDs {
-		ph.registry.Unregister(pluginID)
-		log.Printf("✅ 插件已从注册表中移除: %s", pluginID)
+	for _, plugin := range plugins {
+		ph.registry.Unregister(plugin.ID)
+		ph.logger.Info("✅ 插件已从注册表中移除", "plugin_id", plugin.ID)
+	}
+}
+
+// StopAllPluginsCtx 并发停止所有插件，并等待插件进程退出直到 ctx 截止
+// 截止时仍未退出的插件进程被强制终止，返回的错误包含这些插件的ID（errors.Is 可判断 ErrStopTimeout）
+func (ph *PluginHost) StopAllPluginsCtx(ctx context.Context) error {
+	var plugins []*PluginInfo
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		if plugin.Status == StatusRunning || plugin.Status == StatusDisabled {
+			plugins = append(plugins, plugin)
+		}
+		return true
+	})
+
+	var (
+		wg         sync.WaitGroup
+		mutex      sync.Mutex
+		stragglers []string
+	)
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(plugin *PluginInfo) {
+			defer wg.Done()
+			err := ph.stopPluginCtx(ctx, plugin, proto.ShutdownReason_SHUTDOWN_REASON_HOST_SHUTDOWN)
+			if errors.Is(err, ErrStopTimeout) {
+				mutex.Lock()
+				stragglers = append(stragglers, plugin.ID)
+				mutex.Unlock()
+			}
+		}(plugin)
+	}
+	wg.Wait()
+
+	if len(stragglers) > 0 {
+		sort.Strings(stragglers)
+		return fmt.Errorf("%w: %s", ErrStopTimeout, strings.Join(stragglers, ", "))
+	}
+	return nil
+}
+
+// stopPluginCtx 停止插件并等待停止完成，停止成功后从注册表中移除
+// ctx 截止时强制终止插件进程并返回 ErrStopTimeout，停止流程在后台继续完成清理
+func (ph *PluginHost) stopPluginCtx(ctx context.Context, plugin *PluginInfo, reason proto.ShutdownReason) error {
+	// 进程在停止过程中会被清空，提前记录以便截止时强制终止
+	process := plugin.Process
+
+	done := make(chan error, 1)
+	go func() {
+		err := ph.stopPluginProcess(plugin, reason)
+		if err == nil {
+			ph.registry.Unregister(plugin.ID)
+			ph.logger.Info("✅ 插件已从注册表中移除", "plugin_id", plugin.ID)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 截止与停止完成同时发生时以停止结果为准
+	select {
+	case err := <-done:
+		return err
+	default:
+	}
+
+	ph.logger.Warn("⚠️ 插件未在截止时间内停止，强制终止", "plugin_id", plugin.ID)
+	if process != nil {
+		process.Kill()
+	}
+	return fmt.Errorf("%w: %s", ErrStopTimeout, plugin.ID)
+}
+
+// UpgradePlugin 使用新的可执行文件原地升级插件
+// 通过--info校验新版本（名称相同、主版本号一致）后停止旧进程，
+// 以相同ID启动新版本；新版本启动失败时回滚到原可执行文件
+func (ph *PluginHost) UpgradePlugin(pluginID string, newPath string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+	if plugin.InProcess {
+		return fmt.Errorf("进程内插件 %s 不支持升级", pluginID)
+	}
+	if plugin.Adopted {
+		return fmt.Errorf("%w: %s", ErrPluginAdopted, pluginID)
 	}
+
+	ph.logger.Info("⬆️ 正在升级插件", "plugin_id", pluginID, "from", plugin.ExecutablePath, "to", newPath)
+
+	// 校验新版本插件信息
+	newInfo, err := ph.GetPluginInfo(newPath)
+	if err != nil {
+		return fmt.Errorf("校验新版本插件失败: %v", err)
+	}
+	if newInfo.Name != plugin.Name {
+		return fmt.Errorf("新版本插件名称不匹配: %s != %s", newInfo.Name, plugin.Name)
+	}
+	if !sameMajorVersion(newInfo.Version, plugin.Version) {
+		return fmt.Errorf("新版本插件版本不兼容: %s -> %s", plugin.Version, newInfo.Version)
+	}
+
+	// 保存旧版本信息用于回滚
+	oldPath := plugin.ExecutablePath
+	oldVersion := plugin.Version
+	oldDescription := plugin.Description
+	oldFunctions := plugin.Functions
+	oldTimeouts := plugin.FunctionTimeouts
+	wasRunning := plugin.Process != nil
+
+	// 停止旧进程
+	if wasRunning {
+		if err := ph.stopPluginProcess(plugin, proto.ShutdownReason_SHUTDOWN_REASON_UPGRADE); err != nil {
+			return fmt.Errorf("停止旧版本插件失败: %v", err)
+		}
+	}
+
+	// 切换到新版本并启动
+	plugin.ExecutablePath = newPath
+	plugin.Version = newInfo.Version
+	plugin.Description = newInfo.Description
+	plugin.Functions = newInfo.Functions
+	plugin.FunctionTimeouts = functionTimeouts(newInfo.FunctionTimeouts)
+	plugin.RestartCount = 0
+
+	if err := ph.startPluginProcess(plugin); err != nil {
+		ph.logger.Warn("⚠️ 新版本插件启动失败，回滚到旧版本", "plugin_id", pluginID, "error", err)
+		plugin.ExecutablePath = oldPath
+		plugin.Version = oldVersion
+		plugin.Description = oldDescription
+		plugin.Functions = oldFunctions
+		plugin.FunctionTimeouts = oldTimeouts
+
+		if wasRunning {
+			if rollbackErr := ph.startPluginProcess(plugin); rollbackErr != nil {
+				return fmt.Errorf("启动新版本插件失败: %v，回滚失败: %v", err, rollbackErr)
+			}
+		}
+		return fmt.Errorf("启动新版本插件失败，已回滚: %v", err)
+	}
+
+	ph.logger.Info("✅ 插件升级完成", "plugin_id", pluginID, "old_version", oldVersion, "new_version", newInfo.Version)
+	return nil
+}
+
+// sameMajorVersion 判断两个语义化版本号的主版本号是否一致
+func sameMajorVersion(a, b string) bool {
+	majorOf := func(v string) string {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.Index(v, "."); i >= 0 {
+			return v[:i]
+		}
+		return v
+	}
+	return majorOf(a) == majorOf(b)
+}
+
+// ResetRestartCount 重置插件的重启计数
+// 用于在修复问题后重新启用已放弃重启的插件的自动重启
+func (ph *PluginHost) ResetRestartCount(pluginID string) error {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	plugin.RestartCount = 0
+	ph.logger.Info("🔄 插件重启计数已重置", "plugin_id", pluginID)
+	return nil
+}
+
+// sortByShutdownPriority 按关闭优先级（升序）和插件ID排序
+func sortByShutdownPriority(plugins []*PluginInfo) {
+	sort.SliceStable(plugins, func(i, j int) bool {
+		if plugins[i].ShutdownPriority != plugins[j].ShutdownPriority {
+			return plugins[i].ShutdownPriority < plugins[j].ShutdownPriority
+		}
+		return plugins[i].ID < plugins[j].ID
+	})
 }
 
 // GetPlugin 获取插件信息
@@ -265,46 +740,319 @@ func (ph *PluginHost) GetPlugin(pluginID string) (*PluginInfo, bool) {
 	return ph.registry.Get(pluginID)
 }
 
+// GetPluginsByName 获取指定名称的所有插件（按注册顺序）
+// 同一可执行文件可加载多个实例，名称相同而ID不同；没有同名插件时返回空列表
+func (ph *PluginHost) GetPluginsByName(name string) []*PluginInfo {
+	return ph.registry.GetByName(name)
+}
+
 // GetAllPlugins 获取所有插件
 func (ph *PluginHost) GetAllPlugins() []*PluginInfo {
 	return ph.registry.List()
 }
 
+// HealthSnapshot 获取所有插件健康状态的只读快照（按插件ID排序）
+// 返回值为副本，调用方可安全遍历，不受监控协程更新的影响
+func (ph *PluginHost) HealthSnapshot() []PluginHealth {
+	snapshot := make([]PluginHealth, 0, ph.registry.Count())
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		health := PluginHealth{
+			ID:            plugin.ID,
+			Name:          plugin.Name,
+			Status:        plugin.Status,
+			StartTime:     plugin.StartTime,
+			Uptime:        plugin.Uptime(),
+			RestartCount:  plugin.RestartCount,
+			LastHeartbeat: plugin.LastHeartbeat,
+			ActiveCalls:   plugin.ActiveCalls(),
+			HealthStatus:  plugin.HealthStatus,
+			LastError:     plugin.LastError,
+			LastErrorTime: plugin.LastErrorTime,
+		}
+		if conn := plugin.connection(); conn != nil {
+			health.ConnState = conn.GetState().String()
+		}
+		if len(plugin.HealthDetail) > 0 {
+			health.HealthDetail = make(map[string]string, len(plugin.HealthDetail))
+			for k, v := range plugin.HealthDetail {
+				health.HealthDetail[k] = v
+			}
+		}
+		snapshot = append(snapshot, health)
+		return true
+	})
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].ID < snapshot[j].ID
+	})
+	return snapshot
+}
+
+// PluginConnState 获取主机到插件的gRPC连接状态
+// 用于区分"已注册但连接已断开"（TRANSIENT_FAILURE）与真正健康的插件（READY）
+func (ph *PluginHost) PluginConnState(pluginID string) (connectivity.State, error) {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return connectivity.Shutdown, fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+
+	conn := plugin.connection()
+	if conn == nil {
+		return connectivity.Shutdown, fmt.Errorf("插件 %s gRPC连接未建立", pluginID)
+	}
+	return conn.GetState(), nil
+}
+
+// pingTimeout 存活探测的超时时间
+const pingTimeout = 5 * time.Second
+
+// Ping 测量主机到插件的往返延迟
+// 插件只回显请求内容，不执行任何函数，可作为轻量的就绪检查
+func (ph *PluginHost) Ping(pluginID string) (time.Duration, error) {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return 0, fmt.Errorf("插件 %s 不存在", pluginID)
+	}
+	return ph.ping(plugin)
+}
+
+// ping 向插件发送回显请求并计时
+func (ph *PluginHost) ping(plugin *PluginInfo) (time.Duration, error) {
+	client := plugin.client()
+	if client == nil {
+		return 0, fmt.Errorf("插件 %s gRPC客户端未连接", plugin.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(ph.ctx, pingTimeout)
+	defer cancel()
+
+	payload := fmt.Sprintf("ping-%d", time.Now().UnixNano())
+	start := time.Now()
+	resp, err := client.Echo(ctx, &proto.EchoRequest{Payload: payload})
+	if err != nil {
+		return 0, fmt.Errorf("探测插件失败: %v", err)
+	}
+	if resp.Payload != payload {
+		return 0, fmt.Errorf("插件 %s 回显内容不一致", plugin.ID)
+	}
+	return time.Since(start), nil
+}
+
 // CallPluginFunction 调用插件函数
 func (ph *PluginHost) CallPluginFunction(pluginID string, functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
+	return ph.CallPluginFunctionWithMeta(pluginID, functionName, params, nil)
+}
+
+// CallPluginFunctionWithMeta 调用插件函数并附带自定义元数据（如租户ID、用户、语言）
+// 自定义元数据与框架元数据合并，键冲突时以框架元数据（source、timestamp）为准
+// 插件函数可通过 RequestMetadataFromContext 读取
+func (ph *PluginHost) CallPluginFunctionWithMeta(pluginID string, functionName string, params []*proto.Parameter, meta map[string]string) (*proto.CallResponse, error) {
+	return ph.CallPluginFunctionWithOptions(pluginID, functionName, params, CallOptions{Metadata: meta})
+}
+
+// PluginHasFunction 判断插件的函数列表中是否包含指定函数，插件不存在时返回false
+// 函数列表来自插件信息，插件注册时刷新；通过默认处理器动态提供的函数不在列表中
+func (ph *PluginHost) PluginHasFunction(pluginID string, functionName string) bool {
+	plugin, exists := ph.registry.Get(pluginID)
+	if !exists {
+		return false
+	}
+	return pluginHasFunction(plugin, functionName)
+}
+
+// pluginHasFunction 判断插件的函数列表中是否包含指定函数
+func pluginHasFunction(plugin *PluginInfo, functionName string) bool {
+	for _, name := range plugin.Functions {
+		if name == functionName {
+			return true
+		}
+	}
+	return false
+}
+
+// CallPluginFunctionWithOptions 按调用选项调用插件函数
+// 设置 opts.WaitForReady 时，插件未处于运行状态（如正在自动重启）的调用会等待插件就绪，超时后返回错误
+func (ph *PluginHost) CallPluginFunctionWithOptions(pluginID string, functionName string, params []*proto.Parameter, opts CallOptions) (*proto.CallResponse, error) {
+	meta := opts.Metadata
 	plugin, exists := ph.registry.Get(pluginID)
 	if !exists {
 		return nil, fmt.Errorf("插件 %s 不存在", pluginID)
 	}
 
+	if plugin.Status == StatusDisabled {
+		return nil, fmt.Errorf("%w: %s", ErrPluginDisabled, pluginID)
+	}
 	if plugin.Status != StatusRunning {
-		return nil, fmt.Errorf("插件 %s 状态异常: %s", pluginID, plugin.Status)
+		if opts.WaitForReady <= 0 {
+			return nil, fmt.Errorf("插件 %s 状态异常: %s", pluginID, plugin.Status)
+		}
+		var err error
+		if plugin, err = ph.waitPluginReady(pluginID, opts.WaitForReady); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.RequireFunction && !pluginHasFunction(plugin, functionName) {
+		return nil, fmt.Errorf("%w: %s.%s", ErrFunctionNotFound, pluginID, functionName)
 	}
 
-	if plugin.Client == nil {
+	client := plugin.client()
+	if client == nil {
 		return nil, fmt.Errorf("插件 %s gRPC客户端未连接", pluginID)
 	}
 
+	// 合并元数据，框架管理的键最后写入以覆盖调用方的同名键
+	metadata := make(map[string]string, len(meta)+3)
+	for key, value := range meta {
+		metadata[key] = value
+	}
+	metadata["source"] = "host"
+	metadata["timestamp"] = fmt.Sprintf("%d", time.Now().Unix())
+	metadata[metadataDispatchTime] = fmt.Sprintf("%d", time.Now().UnixNano())
+
 	// 创建请求
 	req := &proto.CallRequest{
 		FunctionName: functionName,
 		Parameters:   params,
 		RequestId:    fmt.Sprintf("host-%d", time.Now().UnixNano()),
-		Metadata: map[string]string{
-			"source":    "host",
-			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
-		},
+		Metadata:     metadata,
+	}
+	if err := ph.checkParamSize(params...); err != nil {
+		ph.logger.Warn("⚠️ 调用插件的参数过大", "plugin_id", pluginID, "function", functionName, "request_id", req.RequestId, "error", err)
+		return paramTooLargeResponse(req.RequestId, err), nil
 	}
 
-	// 调用插件函数
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// 调用插件函数，插件停止时调用立即取消
+	callCtx := plugin.callContext()
+	ctx, cancel := context.WithTimeout(callCtx, ph.callTimeout(plugin, functionName))
 	defer cancel()
 
-	return plugin.Client.CallPluginFunction(ctx, req)
+	atomic.AddInt64(&plugin.activeCalls, 1)
+	defer atomic.AddInt64(&plugin.activeCalls, -1)
+
+	ph.traceCallStart(pluginID, req)
+	start := time.Now()
+	resp, err := client.CallPluginFunction(ctx, req)
+	if err != nil && callCtx.Err() != nil {
+		err = fmt.Errorf("%w: %s", ErrPluginStopped, pluginID)
+		resp = nil
+	}
+	if err == nil {
+		if sizeErr := ph.checkParamSize(resp.Result); sizeErr != nil {
+			ph.logger.Warn("⚠️ 插件返回值过大", "plugin_id", pluginID, "function", functionName, "request_id", req.RequestId, "error", sizeErr)
+			resp = paramTooLargeResponse(req.RequestId, sizeErr)
+		}
+	}
+	elapsed := time.Since(start)
+	ph.traceCallEnd(pluginID, req, resp, err, elapsed)
+	ph.metrics.record(CallMetricKey{
+		Direction: DirectionHostToPlugin,
+		Target:    pluginID,
+		Function:  functionName,
+	}, elapsed, err != nil || !resp.Success)
+	return resp, err
+}
+
+// callTimeout 获取调用插件函数的超时时间
+// 优先使用插件为该函数声明的超时，否则使用 HostConfig.CallTimeout（未配置时30秒）
+func (ph *PluginHost) callTimeout(plugin *PluginInfo, functionName string) time.Duration {
+	if timeout, ok := plugin.FunctionTimeouts[functionName]; ok && timeout > 0 {
+		return timeout
+	}
+	if ph.config.CallTimeout > 0 {
+		return ph.config.CallTimeout
+	}
+	return 30 * time.Second
+}
+
+// functionTimeouts 将插件声明的毫秒超时转换为 time.Duration
+func functionTimeouts(timeoutsMs map[string]int64) map[string]time.Duration {
+	if len(timeoutsMs) == 0 {
+		return nil
+	}
+	timeouts := make(map[string]time.Duration, len(timeoutsMs))
+	for name, ms := range timeoutsMs {
+		timeouts[name] = time.Duration(ms) * time.Millisecond
+	}
+	return timeouts
+}
+
+// waitPluginReady 等待插件进入运行状态
+// 插件每次进入运行状态时 notifyPluginReady 唤醒所有等待者，等待者重新检查目标插件状态
+func (ph *PluginHost) waitPluginReady(pluginID string, timeout time.Duration) (*PluginInfo, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		// 先取通知通道再检查状态，避免错过检查与等待之间发生的就绪通知
+		ph.readyMutex.Lock()
+		ready := ph.readyCh
+		ph.readyMutex.Unlock()
+
+		// 状态由注册、心跳等协程并发更新，需在注册表锁内读取
+		plugin, exists := ph.registry.Get(pluginID)
+		status, _ := ph.registry.GetStatus(pluginID)
+		if !exists {
+			return nil, fmt.Errorf("插件 %s 不存在", pluginID)
+		}
+		if status == StatusRunning {
+			return plugin, nil
+		}
+
+		select {
+		case <-ready:
+		case <-timer.C:
+			return nil, fmt.Errorf("等待插件 %s 就绪超时，当前状态: %s", pluginID, status)
+		case <-ph.ctx.Done():
+			return nil, fmt.Errorf("主机已关闭，停止等待插件 %s", pluginID)
+		}
+	}
+}
+
+// setPluginStatus 通过注册表更新插件状态，状态发生变化时发布事件
+// 所有状态修改都应经过此方法，保证每次状态转换只发布一次事件
+// 返回值：更新前的状态
+func (ph *PluginHost) setPluginStatus(plugin *PluginInfo, status PluginStatus) PluginStatus {
+	old, ok := ph.registry.UpdateStatus(plugin.ID, status)
+	if !ok {
+		// 插件不在注册表中（如已注销），其他流程无法再获取到它，直接更新
+		old = plugin.Status
+		plugin.Status = status
+	}
+
+	if old != status {
+		ph.emitEvent(EventPluginStatusChanged, plugin, fmt.Sprintf("%s -> %s", old, status))
+		if status == StatusRunning {
+			ph.notifyPluginReady(plugin)
+		}
+	}
+	return old
 }
 
-// SendMessageToPlugin 向插件发送消息
+// recordPluginError 记录插件最近一次出错的原因，供健康快照展示
+func (ph *PluginHost) recordPluginError(plugin *PluginInfo, message string) {
+	plugin.LastError = message
+	plugin.LastErrorTime = time.Now()
+}
+
+// notifyPluginReady 通知插件已进入运行状态
+// 唤醒等待就绪的调用并发布 EventPluginReady 事件
+func (ph *PluginHost) notifyPluginReady(plugin *PluginInfo) {
+	ph.readyMutex.Lock()
+	close(ph.readyCh)
+	ph.readyCh = make(chan struct{})
+	ph.readyMutex.Unlock()
+
+	ph.emitEvent(EventPluginReady, plugin, "插件已就绪")
+}
+
+// SendMessageToPlugin 发送消息到插件
 func (ph *PluginHost) SendMessageToPlugin(pluginID string, messageType string, content string, metadata map[string]string) (*proto.MessageResponse, error) {
+	return ph.sendMessage(context.Background(), pluginID, messageType, content, metadata)
+}
+
+// sendMessage 在给定上下文内发送消息到插件，单条消息最长等待60秒
+func (ph *PluginHost) sendMessage(ctx context.Context, pluginID string, messageType string, content string, metadata map[string]string) (*proto.MessageResponse, error) {
 	plugin, exists := ph.registry.Get(pluginID)
 	if !exists {
 		return nil, fmt.Errorf("插件 %s 不存在", pluginID)
@@ -323,10 +1071,14 @@ func (ph *PluginHost) SendMessageToPlugin(pluginID string, messageType string, c
 	}
 
 	// 创建流式连接
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	stream, err := plugin.Client.ReceiveMessages(ctx)
+	client := plugin.client()
+	if client == nil {
+		return nil, fmt.Errorf("插件 %s gRPC客户端未连接", pluginID)
+	}
+	stream, err := client.ReceiveMessages(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("创建消息流失败: %v", err)
 	}
@@ -342,17 +1094,74 @@ func (ph *PluginHost) SendMessageToPlugin(pluginID string, messageType string, c
 
 // BroadcastMessage 广播消息到所有插件
 func (ph *PluginHost) BroadcastMessage(messageType string, content string, metadata map[string]string) map[string]*proto.MessageResponse {
-	plugins := ph.registry.List()
-	results := make(map[string]*proto.MessageResponse)
+	return ph.BroadcastMessageCtx(context.Background(), messageType, content, metadata)
+}
 
-	for _, plugin := range plugins {
+// BroadcastMessageCtx 在给定上下文内并发广播消息到所有运行中的插件
+// 并发数由 BroadcastConcurrency 限制；上下文取消或超时时立即返回已收到的响应
+func (ph *PluginHost) BroadcastMessageCtx(ctx context.Context, messageType string, content string, metadata map[string]string) map[string]*proto.MessageResponse {
+	var targets []*PluginInfo
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
 		if plugin.Status == StatusRunning {
-			resp, err := ph.SendMessageToPlugin(plugin.ID, messageType, content, metadata)
-			if err != nil {
-				log.Printf("向插件 %s 广播消息失败: %v", plugin.ID, err)
-				continue
+			targets = append(targets, plugin)
+		}
+		return true
+	})
+
+	results := make(map[string]*proto.MessageResponse)
+	if len(targets) == 0 {
+		return results
+	}
+
+	workers := ph.config.BroadcastConcurrency
+	if workers <= 0 {
+		workers = 8
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	type broadcastResult struct {
+		pluginID string
+		resp     *proto.MessageResponse
+	}
+
+	jobs := make(chan *PluginInfo)
+	done := make(chan broadcastResult, len(targets)) // 带缓冲，提前返回后工作协程也不会阻塞
+
+	// 分发任务，上下文结束后不再派发
+	go func() {
+		defer close(jobs)
+		for _, plugin := range targets {
+			select {
+			case jobs <- plugin:
+			case <-ctx.Done():
+				return
 			}
-			results[plugin.ID] = resp
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for plugin := range jobs {
+				resp, err := ph.sendMessage(ctx, plugin.ID, messageType, content, metadata)
+				if err != nil {
+					ph.logger.Warn("向插件广播消息失败", "plugin_id", plugin.ID, "error", err)
+				}
+				done <- broadcastResult{pluginID: plugin.ID, resp: resp}
+			}
+		}()
+	}
+
+	for range targets {
+		select {
+		case result := <-done:
+			if result.resp != nil {
+				results[result.pluginID] = result.resp
+			}
+		case <-ctx.Done():
+			ph.logger.Warn("⚠️ 广播消息提前结束", "error", ctx.Err(), "responded", len(results), "total", len(targets))
+			return results
 		}
 	}
 
@@ -361,13 +1170,104 @@ func (ph *PluginHost) BroadcastMessage(messageType string, content string, metad
 
 // RegisterHostFunction 注册主机函数
 func (ph *PluginHost) RegisterHostFunction(name string, fn HostFunction) {
+	ph.funcMutex.Lock()
 	ph.hostFunctions[name] = fn
-	log.Printf("已注册主机函数: %s", name)
+	ph.funcMutex.Unlock()
+	ph.logger.Info("已注册主机函数", "function", name)
+}
+
+// RegisterStreamHostFunction 注册流式主机函数
+// 插件通过 Plugin.CallHostFunctionStream 调用，结果逐条返回，适合日志跟踪等持续输出的场景
+func (ph *PluginHost) RegisterStreamHostFunction(name string, fn StreamHostFunction) {
+	ph.funcMutex.Lock()
+	ph.streamFunctions[name] = fn
+	ph.funcMutex.Unlock()
+	ph.logger.Info("已注册流式主机函数", "function", name)
+}
+
+// UnregisterHostFunction 注销主机函数（包括流式主机函数）
+// 可用于移除内置主机函数；注销后插件调用该函数将返回函数不存在错误
+func (ph *PluginHost) UnregisterHostFunction(name string) {
+	ph.funcMutex.Lock()
+	_, exists := ph.hostFunctions[name]
+	_, streamExists := ph.streamFunctions[name]
+	exists = exists || streamExists
+	delete(ph.hostFunctions, name)
+	delete(ph.streamFunctions, name)
+	ph.funcMutex.Unlock()
+	if exists {
+		ph.logger.Info("已注销主机函数", "function", name)
+	}
+}
+
+// getHostFunction 查找主机函数
+func (ph *PluginHost) getHostFunction(name string) (HostFunction, bool) {
+	ph.funcMutex.RLock()
+	defer ph.funcMutex.RUnlock()
+	fn, exists := ph.hostFunctions[name]
+	return fn, exists
+}
+
+// getStreamHostFunction 查找流式主机函数
+func (ph *PluginHost) getStreamHostFunction(name string) (StreamHostFunction, bool) {
+	ph.funcMutex.RLock()
+	defer ph.funcMutex.RUnlock()
+	fn, exists := ph.streamFunctions[name]
+	return fn, exists
+}
+
+// HasHostFunction 检查主机函数是否已注册（包括流式主机函数）
+// 可用于测试或在启动插件前确认插件依赖的主机函数已注册
+func (ph *PluginHost) HasHostFunction(name string) bool {
+	ph.funcMutex.RLock()
+	defer ph.funcMutex.RUnlock()
+	_, exists := ph.hostFunctions[name]
+	if !exists {
+		_, exists = ph.streamFunctions[name]
+	}
+	return exists
+}
+
+// ListHostFunctions 获取已注册的主机函数名称，包括流式主机函数（按名称排序）
+// 与插件通过 Plugin.ListHostFunctions 查询到的列表一致
+func (ph *PluginHost) ListHostFunctions() []string {
+	ph.funcMutex.RLock()
+	names := make([]string, 0, len(ph.hostFunctions)+len(ph.streamFunctions))
+	for name := range ph.hostFunctions {
+		names = append(names, name)
+	}
+	for name := range ph.streamFunctions {
+		names = append(names, name)
+	}
+	ph.funcMutex.RUnlock()
+
+	sort.Strings(names)
+	return names
 }
 
 // GetPluginInfo 获取插件信息（不加载插件）
+// --info 查询结果按可执行文件缓存，文件修改时间或大小变化后重新查询；需要强制重新读取时使用 RefreshPluginInfo
 func (ph *PluginHost) GetPluginInfo(executablePath string) (*PluginBasicInfo, error) {
-	cmd := exec.Command(executablePath, "--info")
+	// 优先读取清单文件，避免仅为读取元数据而执行插件
+	if ph.config.PreferManifest {
+		info, err := readPluginManifest(manifestPath(executablePath))
+		if err == nil {
+			return info, nil
+		}
+		if !os.IsNotExist(err) {
+			ph.logger.Warn("⚠️ 读取插件清单失败，改用 --info", "path", executablePath, "error", err)
+		}
+	}
+
+	// 可执行文件未变化时使用缓存，避免重复启动插件进程
+	stat, statErr := os.Stat(executablePath)
+	if statErr == nil {
+		if info, ok := ph.infoCache.get(executablePath, stat); ok {
+			return info, nil
+		}
+	}
+
+	cmd := exec.Command(executablePath, infoFlag)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("获取插件信息失败: %v", err)
@@ -379,9 +1279,72 @@ func (ph *PluginHost) GetPluginInfo(executablePath string) (*PluginBasicInfo, er
 		return nil, fmt.Errorf("解析插件信息失败: %v", err)
 	}
 
+	if statErr == nil {
+		ph.infoCache.put(executablePath, stat, &info)
+	}
 	return &info, nil
 }
 
+// manifestPath 获取插件清单文件路径 - 可执行文件旁同名的 .json 文件（去掉 .exe 后缀）
+func manifestPath(executablePath string) string {
+	base := executablePath
+	if strings.EqualFold(filepath.Ext(base), ".exe") {
+		base = base[:len(base)-len(".exe")]
+	}
+	return base + ".json"
+}
+
+// readPluginManifest 读取插件清单文件，格式与 --info 输出相同
+func readPluginManifest(path string) (*PluginBasicInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var info PluginBasicInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("解析插件清单失败: %v", err)
+	}
+	if info.Name == "" {
+		return nil, fmt.Errorf("插件清单 %s 缺少插件名称", path)
+	}
+	return &info, nil
+}
+
+// Uptime 获取主机运行时长，未启动时返回0
+func (ph *PluginHost) Uptime() time.Duration {
+	if ph.startTime.IsZero() {
+		return 0
+	}
+	return time.Since(ph.startTime)
+}
+
+// Stats 获取主机聚合统计信息
+func (ph *PluginHost) Stats() HostStats {
+	stats := HostStats{
+		StartTime:    ph.startTime,
+		Uptime:       ph.Uptime(),
+		CallsServed:  atomic.LoadUint64(&ph.callsServed),
+		PluginPanics: atomic.LoadUint64(&ph.pluginPanics),
+	}
+
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		stats.TotalPlugins++
+		switch plugin.Status {
+		case StatusRunning:
+			stats.RunningPlugins++
+		case StatusCrashed:
+			stats.CrashedPlugins++
+		case StatusStopped:
+			stats.StoppedPlugins++
+		case StatusDisabled:
+			stats.DisabledPlugins++
+		}
+		return true
+	})
+	return stats
+}
+
 // GetActualPort 获取实际使用的端口
 func (ph *PluginHost) GetActualPort() int {
 	return ph.actualPort
@@ -391,6 +1354,56 @@ func (ph *PluginHost) GetActualPort() int {
 
 // startGrpcServer 启动gRPC服务器（自适应端口）
 func (ph *PluginHost) startGrpcServer() error {
+	listener := ph.config.Listener
+	var actualPort int
+
+	// 使用自定义监听器时不再寻找端口
+	if listener != nil {
+		if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+			actualPort = addr.Port
+		}
+		ph.logger.Info("🎯 使用自定义监听器", "address", listener.Addr().String())
+	} else {
+		var err error
+		listener, actualPort, err = ph.listenPortRange()
+		if err != nil {
+			return err
+		}
+	}
+
+	ph.listener = listener
+	ph.actualPort = actualPort
+	ph.grpcServer = grpc.NewServer(ph.config.ServerOptions...)
+
+	// 注册gRPC服务
+	proto.RegisterHostServiceServer(ph.grpcServer, ph.hostService)
+	if ph.config.EnableReflection {
+		reflection.Register(ph.grpcServer)
+		ph.logger.Info("🔍 已启用gRPC服务反射")
+	}
+
+	// 启动服务器，服务意外退出时按 OnServeError 的决定重新监听或关闭主机
+	ph.wg.Add(1)
+	go func() {
+		defer ph.wg.Done()
+		for listener != nil {
+			ph.logger.Info("🌐 gRPC服务器启动中", "port", ph.actualPort)
+			err := ph.grpcServer.Serve(listener)
+			// 主机关闭时 Serve 返回nil
+			if err == nil || ph.ctx.Err() != nil {
+				return
+			}
+			ph.logger.Error("❌ gRPC服务器意外退出", "error", err)
+			listener = ph.handleServeError(err)
+		}
+	}()
+
+	return nil
+}
+
+// listenPortRange 按端口配置寻找可用端口并监听
+// 配置了 Port 时只尝试该端口，否则依次尝试 PortRange 内的端口
+func (ph *PluginHost) listenPortRange() (net.Listener, int, error) {
 	startPort := ph.config.PortRange[0]
 	maxPort := ph.config.PortRange[1]
 
@@ -399,128 +1412,367 @@ func (ph *PluginHost) startGrpcServer() error {
 		maxPort = ph.config.Port
 	}
 
-	var listener net.Listener
-	var err error
-	var actualPort int
+	listener, port, err := listenTCPRange(startPort, maxPort, ph.logger)
+	if err != nil {
+		return nil, 0, err
+	}
+	ph.logger.Info("🎯 找到可用端口", "port", port)
+	return listener, port, nil
+}
 
-	// 自动寻找可用端口
+// listenTCPRange 依次尝试 [startPort, maxPort] 内的端口，返回第一个监听成功的端口
+// 主机和插件共用，范围内的端口全部被占用时返回错误
+func listenTCPRange(startPort, maxPort int, logger Logger) (net.Listener, int, error) {
 	for port := startPort; port <= maxPort; port++ {
-		address := fmt.Sprintf(":%d", port)
-		listener, err = net.Listen("tcp", address)
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 		if err == nil {
-			actualPort = port
-			log.Printf("🎯 找到可用端口: %d", actualPort)
-			break
+			return listener, port, nil
 		}
-		log.Printf("端口 %d 被占用，尝试下一个...", port)
+		logger.Debug("端口被占用，尝试下一个...", "port", port)
 	}
 
-	if listener == nil {
-		return fmt.Errorf("无法找到可用端口 (尝试范围: %d-%d)", startPort, maxPort)
+	return nil, 0, fmt.Errorf("无法找到可用端口 (尝试范围: %d-%d)", startPort, maxPort)
+}
+
+// handleServeError 处理gRPC服务意外退出
+// 返回值：重新监听成功时的新监听器，否则为nil
+func (ph *PluginHost) handleServeError(err error) net.Listener {
+	ph.events.emit(PluginEvent{
+		Type:    EventHostServeFailed,
+		Message: err.Error(),
+		Time:    time.Now(),
+	})
+
+	action := ServeErrorShutdown
+	if ph.config.OnServeError != nil {
+		action = ph.config.OnServeError(err)
 	}
 
-	ph.listener = listener
-	ph.actualPort = actualPort
-	ph.grpcServer = grpc.NewServer()
+	switch action {
+	case ServeErrorIgnore:
+		return nil
+	case ServeErrorRebind:
+		if ph.config.Listener != nil {
+			ph.logger.Error("❌ 使用自定义监听器时无法重新监听，关闭主机")
+			break
+		}
+		listener, port, listenErr := ph.listenPortRange()
+		if listenErr != nil {
+			ph.logger.Error("❌ 重新监听失败，关闭主机", "error", listenErr)
+			break
+		}
+		ph.listener = listener
+		ph.actualPort = port
+		ph.logger.Warn("⚠️ gRPC服务器已重新监听", "port", port)
+
+		// 端口可能变化，更新发现文件使插件重连到新端口
+		if ph.config.DiscoveryFile != "" {
+			if err := ph.writeDiscoveryFile(); err != nil {
+				ph.logger.Warn("⚠️ 写入主机地址发现文件失败", "path", ph.config.DiscoveryFile, "error", err)
+			}
+		}
+		return listener
+	}
 
-	// 注册gRPC服务
-	proto.RegisterHostServiceServer(ph.grpcServer, ph.hostService)
+	// 通知 Wait 返回并关闭主机；Serve 所在协程属于等待组，不能在此直接调用 Stop
+	ph.Shutdown()
+	return nil
+}
 
-	// 启动服务器
-	ph.wg.Add(1)
+// grpcDialOptions 构造gRPC拨号选项，dialer 不为nil时使用自定义拨号函数代替TCP
+// extra 为用户配置的附加选项，追加在默认选项之后，同类选项以后者为准
+func grpcDialOptions(dialer func(ctx context.Context, address string) (net.Conn, error), extra []grpc.DialOption) []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(dialer))
+	}
+	return append(opts, extra...)
+}
+
+// stopGrpcServer 优雅关闭gRPC服务器，超时后强制关闭
+// 防止未关闭的流式调用（如ReceiveMessages）导致GracefulStop无限阻塞
+// timeout 小于等于0时不设超时，等同于GracefulStop
+func stopGrpcServer(server *grpc.Server, timeout time.Duration, logger Logger) {
+	if timeout <= 0 {
+		server.GracefulStop()
+		return
+	}
+
+	done := make(chan struct{})
 	go func() {
-		defer ph.wg.Done()
-		log.Printf("🌐 gRPC服务器启动中，监听端口: %d", actualPort)
-		if err := ph.grpcServer.Serve(listener); err != nil {
-			log.Printf("gRPC服务器错误: %v", err)
-		}
+		server.GracefulStop()
+		close(done)
 	}()
 
-	return nil
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warn("⚠️ gRPC服务器优雅关闭超时，强制关闭", "timeout", timeout)
+		server.Stop()
+		<-done
+	}
 }
 
+// processExitTimeout 停止插件时等待进程退出的最长时间
+const processExitTimeout = 10 * time.Second
+
 // startPluginProcess 启动插件进程
 func (ph *PluginHost) startPluginProcess(plugin *PluginInfo) error {
-	plugin.Status = StatusStarting
+	ph.setPluginStatus(plugin, StatusStarting)
+
+	// 新进程会重新分配端口，清除旧的连接信息，待注册后更新
+	plugin.Port = 0
+	plugin.Address = ""
 
 	// 设置环境变量
 	cmd := exec.Command(plugin.ExecutablePath)
 	cmd.Env = append(os.Environ(),
 		fmt.Sprintf("PLUGIN_ID=%s", plugin.ID),
 		fmt.Sprintf("HOST_GRPC_ADDRESS=localhost:%d", ph.actualPort),
+		fmt.Sprintf("%s=%s", envHostDiscoveryFile, ph.discoveryFilePath()),
 	)
 
+	// 捕获stderr最近输出用于崩溃诊断；插件子进程继承stderr时不因其未退出而阻塞Wait
+	var stderr *tailBuffer
+	if ph.config.StderrTailLines > 0 {
+		stderr = newTailBuffer(ph.config.StderrTailLines)
+		cmd.Stderr = stderr
+		cmd.WaitDelay = time.Second
+	}
+
 	// 启动进程
 	err := cmd.Start()
 	if err != nil {
-		plugin.Status = StatusError
-		return fmt.Errorf("启动插件进程失败: %v", err)
+		err = startProcessError(err)
+		ph.recordPluginError(plugin, err.Error())
+		ph.setPluginStatus(plugin, StatusError)
+		return err
 	}
 
+	exited := make(chan struct{})
+	plugin.resetCallContext(ph.ctx)
 	plugin.Process = cmd.Process
 	plugin.Command = cmd
+	plugin.exited = exited
+	plugin.stderr = stderr
 	plugin.StartTime = time.Now()
 
-	log.Printf("插件进程已启动: %s, PID: %d", plugin.ExecutablePath, plugin.Process.Pid)
+	ph.logger.Info("插件进程已启动", "plugin_id", plugin.ID, "path", plugin.ExecutablePath, "pid", plugin.Process.Pid)
 
-	// 启动进程监控
+	// 启动进程监控，由监控协程统一等待进程退出
 	ph.wg.Add(1)
-	go ph.monitorPluginProcess(plugin)
+	go ph.monitorPluginProcess(plugin, cmd, exited)
 
 	return nil
 }
 
+// startProcessError 区分插件进程启动失败的原因
+// 可执行文件不存在和没有执行权限分别包装为 ErrExecutableNotFound、ErrExecutablePermission
+func startProcessError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%w: %v", ErrExecutableNotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %v", ErrExecutablePermission, err)
+	default:
+		return fmt.Errorf("启动插件进程失败: %v", err)
+	}
+}
+
 // stopPluginProcess 停止插件进程
-func (ph *PluginHost) stopPluginProcess(plugin *PluginInfo) error {
-	plugin.Status = StatusStopping
+// 先请求插件优雅退出（Shutdown RPC，无法发送时使用SIGTERM），等待宽限期后仍未退出再强制终止
+// reason: 关闭原因代码，随Shutdown请求告知插件；主机关闭以外的原因允许插件拒绝关闭
+func (ph *PluginHost) stopPluginProcess(plugin *PluginInfo, reason proto.ShutdownReason) error {
+	previousStatus := ph.setPluginStatus(plugin, StatusStopping)
+
+	// 请求优雅退出，需要在关闭gRPC连接前进行（Windows依赖Shutdown RPC）
+	// 被接管的插件不是本主机的子进程，只能通过Shutdown RPC通知其退出
+	requested, grace := false, time.Duration(0)
+	if (plugin.Process != nil || plugin.client() != nil) && ph.config.StopGracePeriod > 0 {
+		var vetoErr error
+		requested, grace, vetoErr = ph.requestGracefulStop(plugin, reason)
+		if vetoErr != nil {
+			if reason != proto.ShutdownReason_SHUTDOWN_REASON_HOST_SHUTDOWN {
+				ph.setPluginStatus(plugin, previousStatus)
+				ph.logger.Warn("⚠️ 插件拒绝关闭", "plugin_id", plugin.ID, "error", vetoErr)
+				return fmt.Errorf("%w: %v", ErrShutdownVetoed, vetoErr)
+			}
+			ph.logger.Warn("⚠️ 插件拒绝关闭，主机正在关闭，宽限期后强制终止", "plugin_id", plugin.ID, "error", vetoErr)
+		}
+	}
 
-	// 关闭gRPC连接
-	if plugin.Connection != nil {
-		plugin.Connection.Close()
-		plugin.Connection = nil
-		plugin.Client = nil
+	// 取消进行中的调用，避免调用方等待到超时
+	plugin.cancelCalls()
+
+	exited := false
+	if requested {
+		exited = ph.waitProcessExit(plugin, grace)
+		if !exited {
+			ph.logger.Warn("⚠️ 插件未在宽限期内退出，强制终止", "plugin_id", plugin.ID, "grace_period", grace)
+		}
 	}
 
-	// 终止进程
-	if plugin.Process != nil {
-		err := plugin.Process.Kill()
-		plugin.Process = nil
-		if err != nil {
-			log.Printf("终止插件进程失败: %v", err)
+	// 关闭gRPC连接
+	plugin.closeConnection()
+
+	// 强制终止进程
+	if plugin.Process != nil && !exited {
+		if err := plugin.Process.Kill(); err != nil {
+			ph.logger.Error("终止插件进程失败", "plugin_id", plugin.ID, "error", err)
+		}
+
+		// Kill是异步的，等待监控协程确认进程退出，避免端口仍被占用
+		if !ph.waitProcessExit(plugin, processExitTimeout) {
+			ph.logger.Warn("⚠️ 等待插件进程退出超时", "plugin_id", plugin.ID)
 		}
 	}
+	plugin.Process = nil
 
-	plugin.Status = StatusStopped
-	log.Printf("插件已停止: %s", plugin.ID)
+	ph.setPluginStatus(plugin, StatusStopped)
+	ph.logger.Info("插件已停止", "plugin_id", plugin.ID)
 
 	return nil
 }
 
+// requestGracefulStop 请求插件进程优雅退出
+// 优先发送携带原因代码的Shutdown RPC；插件未连接或请求失败时回退到SIGTERM（Windows不支持），
+// 被接管的插件没有本主机启动的进程，无法回退
+// 返回值：请求是否已发出，等待插件退出的宽限期，插件拒绝关闭时的原因
+func (ph *PluginHost) requestGracefulStop(plugin *PluginInfo, reason proto.ShutdownReason) (bool, time.Duration, error) {
+	if client := plugin.client(); client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := client.Shutdown(ctx, &proto.ShutdownRequest{
+			Reason:     shutdownReasonText(reason),
+			ReasonCode: reason,
+		})
+		cancel()
+		if err == nil {
+			if !resp.Success {
+				return true, ph.config.StopGracePeriod, errors.New(resp.Message)
+			}
+			return true, ph.stopGracePeriod(plugin, resp.GraceSeconds), nil
+		}
+		if plugin.Process == nil {
+			ph.logger.Warn("发送关闭请求失败", "plugin_id", plugin.ID, "error", err)
+			return false, 0, nil
+		}
+		ph.logger.Warn("发送关闭请求失败，改用终止信号", "plugin_id", plugin.ID, "error", err)
+	}
+
+	if plugin.Process == nil {
+		return false, 0, nil
+	}
+	if err := terminateProcess(plugin.Process); err != nil {
+		ph.logger.Debug("无法请求插件优雅退出", "plugin_id", plugin.ID, "error", err)
+		return false, 0, nil
+	}
+	return true, ph.config.StopGracePeriod, nil
+}
+
+// stopGracePeriod 计算等待插件退出的宽限期
+// 插件请求了宽限时间时在其基础上再留出 StopGracePeriod 用于清理，总时长不超过 MaxStopGracePeriod（未配置时60秒）
+func (ph *PluginHost) stopGracePeriod(plugin *PluginInfo, requestedSeconds int32) time.Duration {
+	if requestedSeconds <= 0 {
+		return ph.config.StopGracePeriod
+	}
+
+	grace := time.Duration(requestedSeconds)*time.Second + ph.config.StopGracePeriod
+	maxGrace := ph.config.MaxStopGracePeriod
+	if maxGrace <= 0 {
+		maxGrace = 60 * time.Second
+	}
+	if grace > maxGrace {
+		ph.logger.Warn("⚠️ 插件请求的退出宽限期超过上限", "plugin_id", plugin.ID, "requested_seconds", requestedSeconds, "max", maxGrace)
+		grace = maxGrace
+	}
+	return grace
+}
+
+// shutdownReasonText 关闭原因代码对应的描述
+func shutdownReasonText(reason proto.ShutdownReason) string {
+	switch reason {
+	case proto.ShutdownReason_SHUTDOWN_REASON_HOST_SHUTDOWN:
+		return "主机正在关闭"
+	case proto.ShutdownReason_SHUTDOWN_REASON_OPERATOR:
+		return "主机停止插件"
+	case proto.ShutdownReason_SHUTDOWN_REASON_UPGRADE:
+		return "插件升级"
+	default:
+		return "主机停止插件"
+	}
+}
+
+// waitProcessExit 等待监控协程确认插件进程退出，超时返回false
+func (ph *PluginHost) waitProcessExit(plugin *PluginInfo, timeout time.Duration) bool {
+	if plugin.exited == nil {
+		return true
+	}
+	select {
+	case <-plugin.exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // monitorPluginProcess 监控插件进程
-func (ph *PluginHost) monitorPluginProcess(plugin *PluginInfo) {
+// cmd 为本次启动的进程命令，进程退出时若插件已被新进程替换（重启/升级）则不再处理
+// 进程退出后关闭 exited 通知 stopPluginProcess
+func (ph *PluginHost) monitorPluginProcess(plugin *PluginInfo, cmd *exec.Cmd, exited chan struct{}) {
 	defer ph.wg.Done()
 
-	if plugin.Command != nil {
+	if cmd != nil {
 		// 等待进程结束
-		err := plugin.Command.Wait()
+		err := cmd.Wait()
+		close(exited)
+		if plugin.Command != cmd {
+			ph.logger.Info("插件旧进程已退出", "plugin_id", plugin.ID)
+			return
+		}
+		// 插件注册前就退出视为启动即崩溃，退出码为0也不例外
+		if plugin.Status == StatusStarting {
+			err = fmt.Errorf("%w: %v", ErrCrashOnStart, cmd.ProcessState)
+		}
+		crashed := false
 		if err != nil && plugin.Status != StatusStopping && plugin.Status != StatusStopped {
-			log.Printf("插件进程异常退出: %s, 错误: %v", plugin.ID, err)
-			plugin.Status = StatusCrashed
+			tail := strings.Join(plugin.StderrTail(), "\n")
+			ph.logger.Error("插件进程异常退出", "plugin_id", plugin.ID, "error", err, "stderr_tail", tail)
+			ph.recordPluginError(plugin, fmt.Sprintf("插件进程异常退出: %v", err))
+			// 心跳检查已将插件标记为崩溃时，由其负责重启，这里不再重复处理
+			crashed = ph.setPluginStatus(plugin, StatusCrashed) != StatusCrashed
+			if crashed {
+				message := fmt.Sprintf("插件进程异常退出: %v", err)
+				if tail != "" {
+					message += "\nstderr:\n" + tail
+				}
+				ph.emitEvent(EventPluginCrashed, plugin, message)
+			}
 		} else {
-			log.Printf("插件进程正常退出: %s", plugin.ID)
-			plugin.Status = StatusStopped
+			ph.logger.Info("插件进程正常退出", "plugin_id", plugin.ID)
+			ph.setPluginStatus(plugin, StatusStopped)
 		}
 
-		// 检查是否需要自动重启
-		if plugin.AutoRestart && plugin.Status == StatusCrashed && plugin.RestartCount < plugin.MaxRestarts {
-			plugin.RestartCount++
-			log.Printf("自动重启插件: %s (第 %d 次)", plugin.ID, plugin.RestartCount)
-			time.Sleep(5 * time.Second) // 等待一段时间再重启
-			ph.startPluginProcess(plugin)
+		// 检查是否需要自动重启，已禁用的插件等待人工处理
+		if plugin.AutoRestart && crashed && !plugin.Disabled {
+			if plugin.RestartCount < plugin.MaxRestarts {
+				plugin.RestartCount++
+				ph.logger.Warn("自动重启插件", "plugin_id", plugin.ID, "restart_count", plugin.RestartCount)
+				time.Sleep(5 * time.Second) // 等待一段时间再重启
+				ph.startPluginProcess(plugin)
+			} else {
+				ph.pluginGaveUp(plugin)
+			}
 		}
 	}
 }
 
+// pluginGaveUp 插件重启次数耗尽，放弃重启并通知订阅者
+func (ph *PluginHost) pluginGaveUp(plugin *PluginInfo) {
+	message := fmt.Sprintf("插件 %s 已达到最大重启次数 (%d)，放弃重启", plugin.ID, plugin.MaxRestarts)
+	ph.logger.Error("❌ "+message, "plugin_id", plugin.ID)
+	ph.emitEvent(EventPluginGaveUp, plugin, message)
+}
+
 // startMonitoring 启动监控
 func (ph *PluginHost) startMonitoring() {
 	ph.heartbeatTicker = time.NewTicker(ph.config.HeartbeatInterval)
@@ -544,21 +1796,41 @@ func (ph *PluginHost) startMonitoring() {
 // checkPluginsHealth 检查插件健康状态
 func (ph *PluginHost) checkPluginsHealth() {
 	now := time.Now()
-	plugins := ph.registry.List()
+	timeout := ph.config.HeartbeatInterval * time.Duration(ph.config.MaxHeartbeatMiss)
+
+	// 遍历时只找出心跳超时的插件，重启和事件通知在遍历外进行
+	var timedOut []*PluginInfo
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		// 进程内插件没有心跳，不参与检测
+		if plugin.Status == StatusRunning && !plugin.InProcess && now.Sub(plugin.LastHeartbeat) > timeout {
+			timedOut = append(timedOut, plugin)
+		}
+		return true
+	})
+
+	for _, plugin := range timedOut {
+		// 心跳可能只是被延误，插件仍能响应回显时视为存活
+		if latency, err := ph.ping(plugin); err == nil {
+			plugin.LastHeartbeat = time.Now()
+			ph.logger.Warn("⚠️ 插件心跳超时但仍响应探测", "plugin_id", plugin.ID, "latency", latency)
+			continue
+		}
 
-	for _, plugin := range plugins {
-		if plugin.Status == StatusRunning {
-			// 检查心跳超时
-			if now.Sub(plugin.LastHeartbeat) > ph.config.HeartbeatInterval*time.Duration(ph.config.MaxHeartbeatMiss) {
-				log.Printf("插件 %s 心跳超时，标记为崩溃", plugin.ID)
-				plugin.Status = StatusCrashed
-
-				// 检查是否允许自动重启且需要自动重启
-				if ph.config.EnablePluginReconnect && plugin.AutoRestart && plugin.RestartCount < plugin.MaxRestarts {
-					plugin.RestartCount++
-					log.Printf("自动重启心跳超时的插件: %s (第 %d 次)", plugin.ID, plugin.RestartCount)
-					ph.startPluginProcess(plugin)
-				}
+		// 遍历后状态可能已被其他流程改变（如进程退出已标记为崩溃），只处理仍在运行的插件
+		if ph.setPluginStatus(plugin, StatusCrashed) != StatusRunning {
+			continue
+		}
+		ph.logger.Error("插件心跳超时，标记为崩溃", "plugin_id", plugin.ID)
+		ph.recordPluginError(plugin, fmt.Sprintf("心跳超时: 超过 %v 未收到心跳", timeout))
+
+		// 检查是否允许自动重启且需要自动重启
+		if ph.config.EnablePluginReconnect && plugin.AutoRestart {
+			if plugin.RestartCount < plugin.MaxRestarts {
+				plugin.RestartCount++
+				ph.logger.Warn("自动重启心跳超时的插件", "plugin_id", plugin.ID, "restart_count", plugin.RestartCount)
+				ph.startPluginProcess(plugin)
+			} else {
+				ph.pluginGaveUp(plugin)
 			}
 		}
 	}
@@ -574,46 +1846,49 @@ func (ph *PluginHost) registerDefaultFunctions() {
 // 默认主机函数实现
 
 func (ph *PluginHost) getSystemTime(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
-	return &proto.Parameter{
-		Name:  "system_time",
-		Type:  proto.ParameterType_STRING,
-		Value: time.Now().Format("2006-01-02 15:04:05"),
-	}, nil
+	return NewTimeParameter("system_time", time.Now()), nil
 }
 
 func (ph *PluginHost) getSystemInfo(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+	stats := ph.Stats()
 	info := map[string]interface{}{
-		"os":        "windows",
-		"plugins":   ph.registry.Count(),
-		"uptime":    time.Now().Format("2006-01-02 15:04:05"),
-		"grpc_port": ph.actualPort,
+		"os":              runtime.GOOS,
+		"arch":            runtime.GOARCH,
+		"plugins":         stats.TotalPlugins,
+		"running_plugins": stats.RunningPlugins,
+		"crashed_plugins": stats.CrashedPlugins,
+		"stopped_plugins": stats.StoppedPlugins,
+		"calls_served":    stats.CallsServed,
+		"plugin_panics":   stats.PluginPanics,
+		"start_time":      stats.StartTime.Format("2006-01-02 15:04:05"),
+		"uptime":          stats.Uptime.Round(time.Second).String(),
+		"uptime_seconds":  int64(stats.Uptime.Seconds()),
+		"grpc_port":       ph.actualPort,
 	}
 
-	jsonData, _ := json.Marshal(info)
-	return &proto.Parameter{
-		Name:  "system_info",
-		Type:  proto.ParameterType_JSON,
-		Value: string(jsonData),
-	}, nil
+	return NewJSONParameter("system_info", info)
 }
 
 func (ph *PluginHost) getPluginList(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
-	plugins := ph.registry.List()
-	pluginData := make([]map[string]interface{}, len(plugins))
-
-	for i, plugin := range plugins {
-		pluginData[i] = map[string]interface{}{
-			"id":     plugin.ID,
-			"name":   plugin.Name,
-			"status": string(plugin.Status),
-			"port":   plugin.Port,
+	pluginData := make([]map[string]interface{}, 0, ph.registry.Count())
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		startTime := ""
+		if !plugin.StartTime.IsZero() {
+			startTime = plugin.StartTime.Format("2006-01-02 15:04:05")
 		}
-	}
-
-	jsonData, _ := json.Marshal(pluginData)
-	return &proto.Parameter{
-		Name:  "plugin_list",
-		Type:  proto.ParameterType_JSON,
-		Value: string(jsonData),
-	}, nil
+		uptime := plugin.Uptime()
+		pluginData = append(pluginData, map[string]interface{}{
+			"id":             plugin.ID,
+			"name":           plugin.Name,
+			"status":         string(plugin.Status),
+			"port":           plugin.Port,
+			"address":        plugin.Address,
+			"start_time":     startTime,
+			"uptime":         uptime.Round(time.Second).String(),
+			"uptime_seconds": int64(uptime.Seconds()),
+		})
+		return true
+	})
+
+	return NewJSONParameter("plugin_list", pluginData)
 }
@@ -2,13 +2,18 @@ package wwplugin
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/wwwlkj/wwhyplugin/proto"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
 // hostService 主机服务实现
@@ -26,7 +31,7 @@ func newHostService(host *PluginHost) *hostService {
 
 // RegisterPlugin 插件注册
 func (hs *hostService) RegisterPlugin(ctx context.Context, req *proto.RegisterRequest) (*proto.RegisterResponse, error) {
-	log.Printf("插件注册请求: %s (%s)", req.PluginName, req.PluginId)
+	hs.host.logger.Info("插件注册请求", "plugin_name", req.PluginName, "plugin_id", req.PluginId)
 
 	// 查找对应的插件（通过临时ID）
 	plugins := hs.host.registry.List()
@@ -39,49 +44,159 @@ func (hs *hostService) RegisterPlugin(ctx context.Context, req *proto.RegisterRe
 		}
 	}
 
+	// 使用发现文件的主机重启后，接管重启前启动、按新地址重连上来的插件
+	// 只接管重启前记录在插件列表文件中的插件ID，且每个ID只能接管一次
+	if targetPlugin == nil && hs.host.config.DiscoveryFile != "" && hs.host.config.EnablePluginReconnect && hs.host.takeAdoptablePlugin(req.PluginId) {
+		// 无法得知进程的实际启动时间，运行时长从接管时开始计算
+		// 被接管的插件没有可执行文件路径，不启用自动重启
+		targetPlugin = &PluginInfo{ID: req.PluginId, Status: StatusStopped, StartTime: time.Now(), Adopted: true}
+		targetPlugin.resetCallContext(hs.host.ctx) // 被接管的插件不经过 startPluginProcess，停止时同样需要取消进行中的调用
+		hs.host.registry.Register(targetPlugin)
+		hs.host.logger.Info("🔗 接管已在运行的插件", "plugin_id", req.PluginId, "plugin_name", req.PluginName)
+	}
+
 	if targetPlugin == nil {
 		return &proto.RegisterResponse{
-			Success: false,
-			Message: "未找到对应的插件",
+			Success:      false,
+			Message:      "未找到对应的插件",
+			ProtoVersion: proto.ProtoVersion,
 		}, nil
 	}
 
-	// 更新插件信息
+	// 校验协议版本
+	if req.ProtoVersion != proto.ProtoVersion {
+		if hs.host.config.StrictProtoVersion {
+			hs.host.logger.Error("❌ 拒绝插件注册: 协议版本不一致", "plugin_id", req.PluginId, "plugin_proto_version", req.ProtoVersion, "host_proto_version", proto.ProtoVersion)
+			return &proto.RegisterResponse{
+				Success:      false,
+				Message:      fmt.Sprintf("协议版本不一致: 插件 %d, 主机 %d", req.ProtoVersion, proto.ProtoVersion),
+				ProtoVersion: proto.ProtoVersion,
+			}, nil
+		}
+		hs.host.logger.Warn("⚠️ 插件协议版本与主机不一致，请同时重新构建主机和插件", "plugin_id", req.PluginId, "plugin_proto_version", req.ProtoVersion, "host_proto_version", proto.ProtoVersion)
+	}
+
+	// 校验插件依赖的主机函数
+	missing := hs.missingHostFunctions(req.RequiredHostFunctions)
+	if len(missing) > 0 {
+		if hs.host.config.StrictRequiredFunctions {
+			hs.host.logger.Error("❌ 拒绝插件注册: 缺少主机函数", "plugin_id", req.PluginId, "missing", missing)
+			return &proto.RegisterResponse{
+				Success:              false,
+				Message:              fmt.Sprintf("主机缺少插件依赖的函数: %s", strings.Join(missing, ", ")),
+				MissingHostFunctions: missing,
+				ProtoVersion:         proto.ProtoVersion,
+			}, nil
+		}
+		hs.host.logger.Warn("⚠️ 插件依赖的主机函数不存在", "plugin_id", req.PluginId, "missing", missing)
+	}
+
+	// 更新插件信息，状态在ID变化前通过注册表更新
+	hs.host.setPluginStatus(targetPlugin, StatusStarting)
 	oldID := targetPlugin.ID
-	targetPlugin.ID = req.PluginId
+	if oldID != req.PluginId {
+		// 重新注册（如升级回滚后）ID不变时不写入，旧进程的监控协程可能仍在读取
+		targetPlugin.ID = req.PluginId
+	}
 	targetPlugin.Name = req.PluginName
 	targetPlugin.Version = req.Version
 	targetPlugin.Description = req.Description
 	targetPlugin.Port = req.Port
+	targetPlugin.Address = fmt.Sprintf("localhost:%d", req.Port)
 	targetPlugin.Capabilities = req.Capabilities
-	targetPlugin.Status = StatusStarting
+	if len(req.FunctionTimeoutsMs) > 0 {
+		targetPlugin.FunctionTimeouts = functionTimeouts(req.FunctionTimeoutsMs)
+	}
+	if len(req.Functions) > 0 {
+		targetPlugin.Functions = req.Functions
+	}
 	targetPlugin.LastHeartbeat = time.Now()
 
-	// 如果ID发生变化，需要重新注册
+	// 如果ID发生变化，需要重新注册；否则重新注册以刷新能力路由
 	if oldID != req.PluginId {
 		hs.host.registry.Unregister(oldID)
 		hs.host.registry.Register(targetPlugin)
-		log.Printf("🎆 插件注册: %s -> %s", oldID, req.PluginId)
+		hs.host.logger.Info("🎆 插件注册", "old_id", oldID, "plugin_id", req.PluginId)
+	} else {
+		hs.host.registry.Register(targetPlugin)
+	}
+
+	// 签发插件间调用凭证，插件发起插件间调用时携带以证明身份；每次注册都更换
+	callToken, err := newCallToken()
+	if err != nil {
+		hs.host.logger.Error("❌ 生成插件调用凭证失败", "plugin_id", req.PluginId, "error", err)
+		return &proto.RegisterResponse{
+			Success:      false,
+			Message:      fmt.Sprintf("生成调用凭证失败: %v", err),
+			ProtoVersion: proto.ProtoVersion,
+		}, nil
+	}
+	targetPlugin.setCallToken(callToken)
+
+	// 记录已注册的插件ID，主机重启后据此接管重连上来的插件
+	if hs.host.config.DiscoveryFile != "" {
+		if err := hs.host.writeKnownPlugins(); err != nil {
+			hs.host.logger.Warn("⚠️ 写入插件列表文件失败", "path", knownPluginsFilePath(hs.host.config.DiscoveryFile), "error", err)
+		}
 	}
 
 	// 建立到插件的gRPC连接
 	go hs.connectToPlugin(targetPlugin)
 
-	log.Printf("✅ 插件已注册: %s (localhost:%d)", req.PluginName, req.Port)
+	hs.host.logger.Info("✅ 插件已注册", "plugin_name", req.PluginName, "plugin_id", req.PluginId, "address", targetPlugin.Address)
 
 	return &proto.RegisterResponse{
-		Success: true,
-		Message: "注册成功",
-		HostId:  fmt.Sprintf("host-%d", time.Now().Unix()),
+		Success:              true,
+		Message:              "注册成功",
+		HostId:               fmt.Sprintf("host-%d", time.Now().Unix()),
+		MissingHostFunctions: missing,
+		ProtoVersion:         proto.ProtoVersion,
+		CallToken:            callToken,
 	}, nil
 }
 
+// newCallToken 生成随机的插件间调用凭证
+func newCallToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// missingHostFunctions 返回未注册的主机函数名称
+func (hs *hostService) missingHostFunctions(required []string) []string {
+	var missing []string
+	for _, name := range required {
+		if !hs.host.HasHostFunction(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
 // Heartbeat 插件心跳
 func (hs *hostService) Heartbeat(ctx context.Context, req *proto.HeartbeatRequest) (*proto.HeartbeatResponse, error) {
 	// 更新插件心跳时间
 	plugin, exists := hs.host.registry.Get(req.PluginId)
 	if exists {
 		plugin.LastHeartbeat = time.Now()
+		hs.updatePluginHealth(plugin, req)
+
+		// 心跳证明插件仍在服务，之前回连失败的插件在此重新尝试连接
+		if plugin.Status == StatusError && plugin.client() == nil && plugin.Address != "" {
+			hs.host.logger.Info("收到插件心跳，重新尝试回连", "plugin_id", plugin.ID)
+			go hs.connectToPlugin(plugin)
+		}
+	}
+
+	// 未登记的插件（已被停止移除，或主机重启后未被接管）返回失败，插件据此重新注册或退出
+	if !exists {
+		return &proto.HeartbeatResponse{
+			Success:         false,
+			Message:         fmt.Sprintf("插件 %s 未在主机登记", req.PluginId),
+			ServerTimestamp: time.Now().Unix(),
+		}, nil
 	}
 
 	return &proto.HeartbeatResponse{
@@ -91,21 +206,64 @@ func (hs *hostService) Heartbeat(ctx context.Context, req *proto.HeartbeatReques
 	}, nil
 }
 
+// updatePluginHealth 记录心跳上报的健康状态，状态变化时发布事件
+func (hs *hostService) updatePluginHealth(plugin *PluginInfo, req *proto.HeartbeatRequest) {
+	previous := plugin.HealthStatus
+	plugin.HealthStatus = req.Status
+	plugin.HealthDetail = req.Health
+
+	if previous == req.Status {
+		return
+	}
+	if req.Status == HealthRunning {
+		hs.host.logger.Info("插件健康状态变化", "plugin_id", plugin.ID, "from", previous, "to", req.Status)
+	} else {
+		hs.host.logger.Warn("插件健康状态变化", "plugin_id", plugin.ID, "from", previous, "to", req.Status, "detail", req.Health)
+	}
+	hs.host.emitEvent(EventPluginHealthChanged, plugin, fmt.Sprintf("%s -> %s", previous, req.Status))
+}
+
 // CallHostFunction 插件调用主机函数
 func (hs *hostService) CallHostFunction(ctx context.Context, req *proto.CallRequest) (*proto.CallResponse, error) {
+	atomic.AddUint64(&hs.host.callsServed, 1)
+	start := time.Now()
+
 	// 检查是否是插件间调用请求
 	if targetPluginID, exists := req.Metadata["target_plugin_id"]; exists {
 		// 这是插件间调用请求，转发到目标插件
-		return hs.callPluginFunction(ctx, req, targetPluginID)
+		resp, err := hs.callPluginFunction(ctx, req, targetPluginID)
+		hs.host.metrics.record(CallMetricKey{
+			Direction: DirectionPluginToPlugin,
+			Source:    req.Metadata["plugin_id"],
+			Target:    targetPluginID,
+			Function:  req.FunctionName,
+		}, time.Since(start), err != nil || !resp.Success)
+		return resp, err
 	}
 
+	resp, err := hs.callHostFunction(ctx, req)
+	hs.host.metrics.record(CallMetricKey{
+		Direction: DirectionPluginToHost,
+		Source:    req.Metadata["plugin_id"],
+		Function:  req.FunctionName,
+	}, time.Since(start), err != nil || !resp.Success)
+	return resp, err
+}
+
+// callHostFunction 查找并执行主机函数
+func (hs *hostService) callHostFunction(ctx context.Context, req *proto.CallRequest) (*proto.CallResponse, error) {
 	// 正常的主机函数调用
-	log.Printf("插件调用主机函数: %s (请求ID: %s)", req.FunctionName, req.RequestId)
+	hs.host.logger.Info("插件调用主机函数", "function", req.FunctionName, "request_id", req.RequestId, "plugin_id", req.Metadata["plugin_id"])
+
+	if err := hs.host.checkParamSize(req.Parameters...); err != nil {
+		hs.host.logger.Warn("⚠️ 插件传入的参数过大", "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+		return paramTooLargeResponse(req.RequestId, err), nil
+	}
 
 	// 查找函数
-	fn, exists := hs.host.hostFunctions[req.FunctionName]
+	fn, exists := hs.host.getHostFunction(req.FunctionName)
 	if !exists {
-		log.Printf("未找到函数: %s", req.FunctionName)
+		hs.host.logger.Warn("未找到函数", "function", req.FunctionName, "request_id", req.RequestId)
 		return &proto.CallResponse{
 			Success:   false,
 			Message:   fmt.Sprintf("未找到函数: %s", req.FunctionName),
@@ -114,19 +272,32 @@ func (hs *hostService) CallHostFunction(ctx context.Context, req *proto.CallRequ
 		}, nil
 	}
 
-	// 调用函数
-	result, err := fn(ctx, req.Parameters)
+	// 调用函数，请求ID、元数据和主机通过上下文传递给函数
+	result, err := fn(withHost(withRequest(ctx, req.RequestId, req.Metadata), hs.host), req.Parameters)
+	if err == nil {
+		err = validateResult(result)
+	}
+	if err == nil {
+		if err = hs.host.checkParamSize(result); err != nil {
+			hs.host.logger.Warn("⚠️ 主机函数返回值过大", "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+			return paramTooLargeResponse(req.RequestId, err), nil
+		}
+	}
 	if err != nil {
-		log.Printf("函数调用失败: %v", err)
+		hs.host.logger.Error("函数调用失败", "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+		errorCode := "FUNCTION_ERROR"
+		if errors.Is(err, ErrMarshal) {
+			errorCode = ErrorCodeMarshal
+		}
 		return &proto.CallResponse{
 			Success:   false,
 			Message:   err.Error(),
-			ErrorCode: "FUNCTION_ERROR",
+			ErrorCode: errorCode,
 			RequestId: req.RequestId,
 		}, nil
 	}
 
-	log.Printf("函数调用成功: %s", req.FunctionName)
+	hs.host.logger.Info("函数调用成功", "function", req.FunctionName, "request_id", req.RequestId)
 	return &proto.CallResponse{
 		Success:   true,
 		Message:   "调用成功",
@@ -135,12 +306,132 @@ func (hs *hostService) CallHostFunction(ctx context.Context, req *proto.CallRequ
 	}, nil
 }
 
+// CallHostFunctionStream 插件调用流式主机函数
+// 每条结果作为一个成功响应发送；函数出错时发送一个失败响应后结束
+func (hs *hostService) CallHostFunctionStream(req *proto.CallRequest, stream proto.HostService_CallHostFunctionStreamServer) (err error) {
+	atomic.AddUint64(&hs.host.callsServed, 1)
+
+	// 流式调用以整个流计一次调用，发送失败响应也计为失败
+	start := time.Now()
+	failed := false
+	defer func() {
+		hs.host.metrics.record(CallMetricKey{
+			Direction: DirectionPluginToHost,
+			Source:    req.Metadata["plugin_id"],
+			Function:  req.FunctionName,
+		}, time.Since(start), failed || err != nil)
+	}()
+	hs.host.logger.Info("插件调用流式主机函数", "function", req.FunctionName, "request_id", req.RequestId, "plugin_id", req.Metadata["plugin_id"])
+
+	// 查找函数
+	fn, exists := hs.host.getStreamHostFunction(req.FunctionName)
+	if !exists {
+		hs.host.logger.Warn("未找到流式函数", "function", req.FunctionName, "request_id", req.RequestId)
+		failed = true
+		return stream.Send(&proto.CallResponse{
+			Success:   false,
+			Message:   fmt.Sprintf("未找到流式函数: %s", req.FunctionName),
+			ErrorCode: "FUNCTION_NOT_FOUND",
+			RequestId: req.RequestId,
+		})
+	}
+
+	// 逐条校验并发送结果
+	sent := 0
+	send := func(result *proto.Parameter) error {
+		if err := validateResult(result); err != nil {
+			return err
+		}
+		if err := stream.Send(&proto.CallResponse{
+			Success:   true,
+			Result:    result,
+			RequestId: req.RequestId,
+		}); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	}
+
+	// 调用函数，插件取消调用时上下文随之取消
+	if err := fn(withHost(withRequest(stream.Context(), req.RequestId, req.Metadata), hs.host), req.Parameters, send); err != nil {
+		failed = true
+		if stream.Context().Err() != nil {
+			hs.host.logger.Info("插件已取消流式调用", "function", req.FunctionName, "request_id", req.RequestId, "sent", sent)
+			return stream.Context().Err()
+		}
+
+		hs.host.logger.Error("流式函数调用失败", "function", req.FunctionName, "request_id", req.RequestId, "sent", sent, "error", err)
+		errorCode := "FUNCTION_ERROR"
+		if errors.Is(err, ErrMarshal) {
+			errorCode = ErrorCodeMarshal
+		}
+		return stream.Send(&proto.CallResponse{
+			Success:   false,
+			Message:   err.Error(),
+			ErrorCode: errorCode,
+			RequestId: req.RequestId,
+		})
+	}
+
+	hs.host.logger.Info("流式函数调用完成", "function", req.FunctionName, "request_id", req.RequestId, "sent", sent)
+	return nil
+}
+
 // callPluginFunction 插件间调用函数（新增）
 // 允许一个插件通过主机调用另一个插件的函数
 func (hs *hostService) callPluginFunction(ctx context.Context, req *proto.CallRequest, targetPluginID string) (*proto.CallResponse, error) {
 	// 获取调用者插件ID
 	sourcePluginID := req.Metadata["plugin_id"]
-	log.Printf("插件间调用: %s -> %s.%s", sourcePluginID, targetPluginID, req.FunctionName)
+	hs.host.logger.Info("插件间调用", "source_plugin", sourcePluginID, "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId)
+
+	// 确认调用方身份：plugin_id 由调用方自行填写，必须是正在运行的已登记插件并携带注册时签发的凭证，
+	// 否则插件可冒用其他插件的ID借用其调用策略
+	sourcePlugin, exists := hs.host.registry.Get(sourcePluginID)
+	sourceStatus, _ := hs.host.registry.GetStatus(sourcePluginID)
+	if !exists || sourceStatus != StatusRunning || !sourcePlugin.verifyCallToken(req.Metadata[metadataCallToken]) {
+		hs.host.logger.Warn("⚠️ 插件间调用被拒绝: 无法确认调用方身份", "source_plugin", sourcePluginID, "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId)
+		return &proto.CallResponse{
+			Success:   false,
+			Message:   fmt.Sprintf("无法确认调用方插件 %s 的身份", sourcePluginID),
+			ErrorCode: "CALLER_NOT_VERIFIED",
+			RequestId: req.RequestId,
+		}, nil
+	}
+
+	// 检测循环调用：目标插件已在调用链路中时拒绝
+	path := splitCallPath(req.Metadata[metadataCallPath])
+	if len(path) == 0 || path[len(path)-1] != sourcePluginID {
+		path = append(path, sourcePluginID)
+	}
+	for _, pluginID := range path {
+		if pluginID == targetPluginID {
+			loop := strings.Join(append(path, targetPluginID), " -> ")
+			hs.host.logger.Warn("⚠️ 检测到插件间循环调用", "path", loop, "function", req.FunctionName, "request_id", req.RequestId)
+			return &proto.CallResponse{
+				Success:   false,
+				Message:   fmt.Sprintf("检测到循环调用: %s", loop),
+				ErrorCode: "CALL_LOOP_DETECTED",
+				RequestId: req.RequestId,
+			}, nil
+		}
+	}
+
+	// 检查插件间调用策略
+	if !hs.host.interPluginAllowed(sourcePluginID, targetPluginID) {
+		hs.host.logger.Warn("⚠️ 插件间调用被策略拒绝", "source_plugin", sourcePluginID, "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId)
+		return &proto.CallResponse{
+			Success:   false,
+			Message:   fmt.Sprintf("插件 %s 不允许调用插件 %s", sourcePluginID, targetPluginID),
+			ErrorCode: "INTER_PLUGIN_DENIED",
+			RequestId: req.RequestId,
+		}, nil
+	}
+
+	if err := hs.host.checkParamSize(req.Parameters...); err != nil {
+		hs.host.logger.Warn("⚠️ 插件间调用参数过大", "source_plugin", sourcePluginID, "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+		return paramTooLargeResponse(req.RequestId, err), nil
+	}
 
 	// 获取目标插件信息
 	targetPlugin, exists := hs.host.registry.Get(targetPluginID)
@@ -153,19 +444,24 @@ func (hs *hostService) callPluginFunction(ctx context.Context, req *proto.CallRe
 		}, nil
 	}
 
-	// 检查目标插件状态
-	if targetPlugin.Status != StatusRunning {
+	// 检查目标插件状态，并取客户端快照：停止流程可能随时清空客户端
+	status, _ := hs.host.registry.GetStatus(targetPluginID)
+	client := targetPlugin.client()
+	if status != StatusRunning || client == nil {
 		return &proto.CallResponse{
 			Success:   false,
-			Message:   fmt.Sprintf("目标插件 %s 状态异常: %s", targetPluginID, targetPlugin.Status),
+			Message:   fmt.Sprintf("目标插件 %s 状态异常: %s", targetPluginID, status),
 			ErrorCode: "TARGET_PLUGIN_NOT_RUNNING",
 			RequestId: req.RequestId,
 		}, nil
 	}
 
-	// 调用目标插件函数
-	callCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// 调用目标插件函数，目标插件停止或调用方取消时调用立即取消
+	pluginCtx := targetPlugin.callContext()
+	callCtx, cancel := context.WithTimeout(pluginCtx, hs.interPluginTimeout(ctx, targetPlugin, req.FunctionName))
 	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
 
 	// 更新元数据，标明这是插件间调用
 	enhancedReq := &proto.CallRequest{
@@ -178,12 +474,26 @@ func (hs *hostService) callPluginFunction(ctx context.Context, req *proto.CallRe
 			"target_plugin": targetPluginID,
 			"timestamp":     fmt.Sprintf("%d", time.Now().Unix()),
 			"via_host":      "true",
+
+			metadataDispatchTime: fmt.Sprintf("%d", time.Now().UnixNano()),
+			metadataCallPath:     joinCallPath(path),
 		},
 	}
 
-	resp, err := targetPlugin.Client.CallPluginFunction(callCtx, enhancedReq)
+	atomic.AddInt64(&targetPlugin.activeCalls, 1)
+	resp, err := client.CallPluginFunction(callCtx, enhancedReq)
+	atomic.AddInt64(&targetPlugin.activeCalls, -1)
+	if err != nil && pluginCtx.Err() != nil {
+		hs.host.logger.Warn("插件间调用失败: 目标插件已停止", "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId)
+		return &proto.CallResponse{
+			Success:   false,
+			Message:   fmt.Sprintf("%v: %s", ErrPluginStopped, targetPluginID),
+			ErrorCode: ErrorCodePluginStopped,
+			RequestId: req.RequestId,
+		}, nil
+	}
 	if err != nil {
-		log.Printf("插件间调用失败: %v", err)
+		hs.host.logger.Error("插件间调用失败", "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId, "error", err)
 		return &proto.CallResponse{
 			Success:   false,
 			Message:   fmt.Sprintf("调用目标插件函数失败: %v", err),
@@ -192,46 +502,240 @@ func (hs *hostService) callPluginFunction(ctx context.Context, req *proto.CallRe
 		}, nil
 	}
 
-	log.Printf("插件间调用成功: %s -> %s.%s", sourcePluginID, targetPluginID, req.FunctionName)
+	if err := hs.host.checkParamSize(resp.Result); err != nil {
+		hs.host.logger.Warn("⚠️ 插件间调用返回值过大", "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+		resp = paramTooLargeResponse(req.RequestId, err)
+	}
+
+	// 标明实际处理调用的插件，便于调用方排查能力路由的结果
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]string, 2)
+	}
+	resp.Metadata[MetadataServingPluginID] = targetPlugin.ID
+	resp.Metadata[MetadataServingPluginName] = targetPlugin.Name
+
+	hs.host.logger.Info("插件间调用成功", "source_plugin", sourcePluginID, "plugin_id", targetPluginID, "function", req.FunctionName, "request_id", req.RequestId)
 	return resp, nil
 }
 
+// interPluginTimeout 获取转发插件间调用的超时时间
+// 使用 HostConfig.InterPluginTimeout（未配置时同直接调用），且不超过调用方请求剩余的截止时间
+func (hs *hostService) interPluginTimeout(ctx context.Context, target *PluginInfo, functionName string) time.Duration {
+	timeout := hs.host.config.InterPluginTimeout
+	if timeout <= 0 {
+		timeout = hs.host.callTimeout(target, functionName)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return timeout
+}
+
+// checkParamSize 检查参数编码后的总大小是否超过 MaxParamBytes，未配置时不限制
+func (ph *PluginHost) checkParamSize(params ...*proto.Parameter) error {
+	limit := ph.config.MaxParamBytes
+	if limit <= 0 {
+		return nil
+	}
+
+	total := 0
+	for _, param := range params {
+		if param != nil {
+			total += protobuf.Size(param)
+		}
+	}
+	if total > limit {
+		return fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrParamTooLarge, total, limit)
+	}
+	return nil
+}
+
+// paramTooLargeResponse 参数超过大小限制时的失败响应
+func paramTooLargeResponse(requestID string, err error) *proto.CallResponse {
+	return &proto.CallResponse{
+		Success:   false,
+		Message:   err.Error(),
+		ErrorCode: ErrorCodeParamTooLarge,
+		RequestId: requestID,
+	}
+}
+
+// validateResult 校验主机函数返回值能否正确序列化
+// JSON类型的值必须是合法JSON，且整个参数必须能被protobuf编码
+func validateResult(result *proto.Parameter) error {
+	if result == nil {
+		return nil
+	}
+	switch result.Type {
+	case proto.ParameterType_JSON:
+		if !json.Valid([]byte(result.Value)) {
+			return fmt.Errorf("%w: 参数 %s 不是合法的JSON", ErrMarshal, result.Name)
+		}
+	case proto.ParameterType_TIMESTAMP:
+		if _, err := result.AsTime(); err != nil {
+			return fmt.Errorf("%w: %v", ErrMarshal, err)
+		}
+	case proto.ParameterType_DURATION:
+		if _, err := result.AsDuration(); err != nil {
+			return fmt.Errorf("%w: %v", ErrMarshal, err)
+		}
+	}
+	if _, err := protobuf.Marshal(result); err != nil {
+		return fmt.Errorf("%w: %v", ErrMarshal, err)
+	}
+	return nil
+}
+
 // ReportLog 插件上报日志
 func (hs *hostService) ReportLog(ctx context.Context, req *proto.LogRequest) (*proto.LogResponse, error) {
-	// 格式化日志信息
-	levelStr := req.Level.String()
-	timestamp := time.Unix(req.Timestamp, 0).Format("2006-01-02 15:04:05")
+	// 插件函数panic单独突出显示并计数
+	if req.Category == logCategoryPanic {
+		atomic.AddUint64(&hs.host.pluginPanics, 1)
+		hs.host.logger.Error("🔥 插件函数发生panic", "plugin_id", req.PluginId, "detail", req.Message)
+		if plugin, exists := hs.host.registry.Get(req.PluginId); exists {
+			hs.host.emitEvent(EventPluginPanic, plugin, req.Message)
+		}
+		return &proto.LogResponse{
+			Success: true,
+		}, nil
+	}
 
-	// 输出日志
-	log.Printf("[%s] [%s] [%s] %s", timestamp, levelStr, req.PluginId, req.Message)
+	// 按插件上报的级别输出到主机日志
+	fields := []interface{}{
+		"plugin_id", req.PluginId,
+		"time", time.Unix(req.Timestamp, 0).Format("2006-01-02 15:04:05"),
+	}
+	switch req.Level {
+	case proto.LogLevel_DEBUG:
+		hs.host.logger.Debug(req.Message, fields...)
+	case proto.LogLevel_WARN:
+		hs.host.logger.Warn(req.Message, fields...)
+	case proto.LogLevel_ERROR:
+		hs.host.logger.Error(req.Message, fields...)
+	default:
+		hs.host.logger.Info(req.Message, fields...)
+	}
 
 	return &proto.LogResponse{
 		Success: true,
 	}, nil
 }
 
+// ReportLogs 插件批量上报日志
+func (hs *hostService) ReportLogs(ctx context.Context, req *proto.LogBatchRequest) (*proto.LogResponse, error) {
+	if req.Dropped > 0 {
+		hs.host.logger.Warn("⚠️ 插件日志过多，部分日志已被丢弃", "plugin_id", req.PluginId, "dropped", req.Dropped)
+	}
+
+	for _, entry := range req.Entries {
+		if entry.PluginId == "" {
+			entry.PluginId = req.PluginId
+		}
+		hs.ReportLog(ctx, entry)
+	}
+
+	return &proto.LogResponse{
+		Success: true,
+	}, nil
+}
+
+// UpdateCapabilities 插件运行时更新能力列表
+// 同步更新插件信息和能力路由表
+func (hs *hostService) UpdateCapabilities(ctx context.Context, req *proto.CapabilitiesRequest) (*proto.CapabilitiesResponse, error) {
+	plugin, exists := hs.host.registry.Get(req.PluginId)
+	if !exists {
+		return &proto.CapabilitiesResponse{
+			Success: false,
+			Message: fmt.Sprintf("插件 %s 不存在", req.PluginId),
+		}, nil
+	}
+
+	plugin.Capabilities = req.Capabilities
+	hs.host.registry.Register(plugin)
+
+	hs.host.logger.Info("插件能力已更新", "plugin_id", req.PluginId, "capabilities", req.Capabilities)
+	return &proto.CapabilitiesResponse{
+		Success: true,
+		Message: "能力已更新",
+	}, nil
+}
+
+// ListHostFunctions 插件查询主机函数列表
+// 插件可在启动时确认依赖的主机函数是否存在
+func (hs *hostService) ListHostFunctions(ctx context.Context, req *proto.ListFunctionsRequest) (*proto.ListFunctionsResponse, error) {
+	return &proto.ListFunctionsResponse{
+		FunctionNames: hs.host.ListHostFunctions(),
+	}, nil
+}
+
+// Echo 原样返回请求内容，供插件测量到主机的往返延迟
+func (hs *hostService) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.EchoResponse, error) {
+	return &proto.EchoResponse{Payload: req.Payload}, nil
+}
+
 // connectToPlugin 连接到插件
+// 按配置的次数重试，全部失败时标记为StatusError，等待插件下次心跳再尝试
 func (hs *hostService) connectToPlugin(plugin *PluginInfo) {
-	// 等待一段时间让插件启动gRPC服务
-	time.Sleep(2 * time.Second)
+	// 同一插件同时只允许一个回连过程
+	if !atomic.CompareAndSwapInt32(&plugin.connecting, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&plugin.connecting, 0)
 
-	address := fmt.Sprintf("localhost:%d", plugin.Port)
-	log.Printf("连接到插件: %s (%s)", plugin.ID, address)
+	// 插件在确认自身gRPC服务就绪后才会注册，这里可以直接连接
+
+	attempts := hs.host.config.ConnectRetries + 1
+	if attempts < 1 {
+		attempts = 1
+	}
 
-	// 建立gRPC连接
-	conn, err := grpc.Dial(
-		address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		hs.host.logger.Info("连接到插件", "plugin_id", plugin.ID, "address", plugin.Address, "attempt", attempt, "attempts", attempts)
+
+		if err = hs.dialPlugin(plugin); err == nil {
+			hs.host.logger.Info("✅ 已连接到插件", "plugin_id", plugin.ID)
+			// 在插件开始接收调用前推送配置
+			if err := hs.host.pushPluginConfig(plugin); err != nil {
+				hs.host.logger.Warn("⚠️ 推送插件配置失败", "plugin_id", plugin.ID, "error", err)
+			}
+			if plugin.Disabled {
+				hs.host.setPluginStatus(plugin, StatusDisabled)
+			} else {
+				hs.host.setPluginStatus(plugin, StatusRunning)
+			}
+			return
+		}
+
+		hs.host.logger.Warn("连接插件失败", "plugin_id", plugin.ID, "error", err)
+		if attempt < attempts {
+			time.Sleep(hs.host.config.ConnectRetryInterval)
+		}
+	}
+
+	hs.host.logger.Error("❌ 多次连接插件失败，将在收到下次心跳时重试", "plugin_id", plugin.ID)
+	hs.host.recordPluginError(plugin, fmt.Sprintf("连接插件失败: %v", err))
+	hs.host.setPluginStatus(plugin, StatusError)
+}
+
+// dialPlugin 建立到插件的gRPC连接并确认插件服务可用
+func (hs *hostService) dialPlugin(plugin *PluginInfo) error {
+	conn, err := grpc.Dial(plugin.Address, grpcDialOptions(hs.host.config.Dialer, hs.host.config.DialOptions)...)
 	if err != nil {
-		log.Printf("连接插件失败: %v", err)
-		plugin.Status = StatusError
-		return
+		return err
 	}
 
-	plugin.Connection = conn
-	plugin.Client = proto.NewPluginServiceClient(conn)
+	// grpc.Dial 不会真正建立连接，通过状态查询确认插件正在服务
+	client := proto.NewPluginServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.GetPluginStatus(ctx, &proto.StatusRequest{}); err != nil {
+		conn.Close()
+		return err
+	}
 
-	log.Printf("✅ 已连接到插件: %s", plugin.ID)
-	plugin.Status = StatusRunning
+	plugin.setConnection(conn, client)
+	return nil
 }
@@ -0,0 +1,405 @@
+package wwplugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwwlkj/wwhyplugin/proto"
+)
+
+// testParamLimit 参数大小测试使用的上限
+const testParamLimit = 1024
+
+// largeParameter 构造编码后超过 testParamLimit 的参数
+func largeParameter(name string) *proto.Parameter {
+	return &proto.Parameter{Name: name, Type: proto.ParameterType_STRING, Value: strings.Repeat("x", 2*testParamLimit)}
+}
+
+// newParamLimitHost 创建限制参数大小的测试主机，并连接提供 Echo、Large 函数的插件
+func newParamLimitHost(t *testing.T) (*TestHost, *Plugin) {
+	t.Helper()
+	th := newTestHost(t)
+	th.config.MaxParamBytes = testParamLimit
+	th.RegisterHostFunction("Large", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+		return largeParameter("result"), nil
+	})
+	th.RegisterHostFunction("Echo", HostFunction(returnString("ok")))
+
+	plugin := connectTestPlugin(t, th, "ParamLimitPlugin", func(p *Plugin) {
+		p.RegisterFunction("Large", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+			return largeParameter("result"), nil
+		})
+		p.RegisterFunction("Echo", returnString("ok"))
+	})
+	return th, plugin
+}
+
+// TestParamTooLargeHostToPlugin 主机调用插件时参数或返回值超限返回 PARAM_TOO_LARGE
+func TestParamTooLargeHostToPlugin(t *testing.T) {
+	th, plugin := newParamLimitHost(t)
+
+	cases := []struct {
+		name     string
+		function string
+		params   []*proto.Parameter
+	}{
+		{"参数超限", "Echo", []*proto.Parameter{largeParameter("input")}},
+		{"返回值超限", "Large", nil},
+	}
+	for _, c := range cases {
+		resp, err := th.CallPluginFunction(plugin.ID, c.function, c.params)
+		if err != nil {
+			t.Fatalf("%s: 调用失败: %v", c.name, err)
+		}
+		if resp.ErrorCode != ErrorCodeParamTooLarge {
+			t.Fatalf("%s: 错误码为 %q，期望 %q", c.name, resp.ErrorCode, ErrorCodeParamTooLarge)
+		}
+	}
+}
+
+// TestParamTooLargePluginToHost 插件调用主机函数时参数或返回值超限返回 PARAM_TOO_LARGE
+func TestParamTooLargePluginToHost(t *testing.T) {
+	_, plugin := newParamLimitHost(t)
+
+	cases := []struct {
+		name     string
+		function string
+		params   []*proto.Parameter
+	}{
+		{"参数超限", "Echo", []*proto.Parameter{largeParameter("input")}},
+		{"返回值超限", "Large", nil},
+	}
+	for _, c := range cases {
+		resp, err := plugin.CallHostFunction(c.function, c.params)
+		if err != nil {
+			t.Fatalf("%s: 调用失败: %v", c.name, err)
+		}
+		if resp.ErrorCode != ErrorCodeParamTooLarge {
+			t.Fatalf("%s: 错误码为 %q，期望 %q", c.name, resp.ErrorCode, ErrorCodeParamTooLarge)
+		}
+	}
+
+	// 未超限的调用不受影响
+	resp, err := plugin.CallHostFunction("Echo", nil)
+	if err != nil || !resp.Success {
+		t.Fatalf("未超限的调用失败: %v %v", resp, err)
+	}
+}
+
+// TestHostFunctionMarshalError 主机函数返回值无法序列化时返回 MARSHAL_ERROR
+func TestHostFunctionMarshalError(t *testing.T) {
+	th := newTestHost(t)
+
+	// 通道无法被JSON序列化，NewJSONParameter 返回 ErrMarshal
+	th.RegisterHostFunction("Channel", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+		return NewJSONParameter("result", make(chan int))
+	})
+	// 手工构造的JSON类型返回值不是合法JSON，由主机在返回前校验
+	th.RegisterHostFunction("InvalidJSON", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+		return &proto.Parameter{Name: "result", Type: proto.ParameterType_JSON, Value: "{"}, nil
+	})
+
+	plugin := connectTestPlugin(t, th, "MarshalPlugin", nil)
+
+	for _, function := range []string{"Channel", "InvalidJSON"} {
+		resp, err := plugin.CallHostFunction(function, nil)
+		if err != nil {
+			t.Fatalf("%s: 调用失败: %v", function, err)
+		}
+		if resp.Success || resp.ErrorCode != ErrorCodeMarshal {
+			t.Fatalf("%s: 响应为 success=%v error_code=%q，期望 %q", function, resp.Success, resp.ErrorCode, ErrorCodeMarshal)
+		}
+	}
+}
+
+// TestPluginPortOnRunning 插件进入运行状态后端口和地址已填充，GetPluginList 返回相同的连接信息
+func TestPluginPortOnRunning(t *testing.T) {
+	th := newTestHost(t)
+
+	plugin := connectTestPlugin(t, th, "PortPlugin", nil)
+
+	info, exists := th.GetPlugin(plugin.ID)
+	if !exists {
+		t.Fatalf("插件 %s 未注册", plugin.ID)
+	}
+	if status, _ := th.registry.GetStatus(plugin.ID); status != StatusRunning {
+		t.Fatalf("插件状态为 %s，期望 %s", status, StatusRunning)
+	}
+	if info.Port == 0 || info.Address == "" {
+		t.Fatalf("运行中的插件端口为 %d、地址为 %q，期望已填充", info.Port, info.Address)
+	}
+
+	resp, err := plugin.CallHostFunction("GetPluginList", nil)
+	if err != nil || !resp.Success {
+		t.Fatalf("调用 GetPluginList 失败: %v %v", resp, err)
+	}
+	var list []struct {
+		ID      string `json:"id"`
+		Port    int32  `json:"port"`
+		Address string `json:"address"`
+	}
+	if err := resp.Result.DecodeJSON(&list); err != nil {
+		t.Fatalf("解析插件列表失败: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != plugin.ID || list[0].Port != info.Port || list[0].Address != info.Address {
+		t.Fatalf("插件列表为 %+v，期望端口 %d、地址 %q", list, info.Port, info.Address)
+	}
+}
+
+// TestAdoptOnlyKnownPlugins 重启后的主机只接管插件列表文件中记录的插件ID，且同一ID只接管一次
+func TestAdoptOnlyKnownPlugins(t *testing.T) {
+	th := newTestHost(t)
+
+	th.config.DiscoveryFile = filepath.Join(t.TempDir(), "host.addr")
+	th.config.EnablePluginReconnect = true
+	if err := os.WriteFile(knownPluginsFilePath(th.config.DiscoveryFile), []byte(`["known-plugin"]`), 0600); err != nil {
+		t.Fatalf("写入插件列表文件失败: %v", err)
+	}
+	th.loadKnownPlugins()
+
+	register := func(pluginID string) *proto.RegisterResponse {
+		resp, err := th.hostService.RegisterPlugin(context.Background(), &proto.RegisterRequest{
+			PluginId:     pluginID,
+			PluginName:   "AdoptPlugin",
+			Version:      "1.0.0",
+			ProtoVersion: proto.ProtoVersion,
+		})
+		if err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", pluginID, err)
+		}
+		return resp
+	}
+
+	if resp := register("unknown-plugin"); resp.Success {
+		t.Fatal("未记录的插件ID不应被接管")
+	}
+	if resp := register("known-plugin"); !resp.Success {
+		t.Fatalf("记录的插件ID应被接管: %s", resp.Message)
+	}
+	if data, err := os.ReadFile(knownPluginsFilePath(th.config.DiscoveryFile)); err != nil || !strings.Contains(string(data), "known-plugin") {
+		t.Fatalf("接管后插件列表文件应保留该插件ID: %s, %v", data, err)
+	}
+
+	// 接管后从注册表移除，同一ID不能再次接管
+	th.registry.Unregister("known-plugin")
+	if resp := register("known-plugin"); resp.Success {
+		t.Fatal("同一插件ID不应被接管两次")
+	}
+}
+
+// TestStopAdoptedPlugin 停止被接管的插件时主机发送关闭请求，插件随之退出；被接管的插件不能升级或重新启动
+func TestStopAdoptedPlugin(t *testing.T) {
+	th := newTestHost(t)
+	th.config.DiscoveryFile = filepath.Join(t.TempDir(), "host.addr")
+	th.config.EnablePluginReconnect = true
+	plugin := connectTestPlugin(t, th, "AdoptedPlugin", nil)
+
+	// 模拟主机重启：新主机的注册表中没有该插件，插件ID记录在插件列表文件中
+	info, _ := th.registry.Get(plugin.ID)
+	info.closeConnection()
+	th.registry.Unregister(plugin.ID)
+	if resp, err := th.hostService.Heartbeat(context.Background(), &proto.HeartbeatRequest{PluginId: plugin.ID}); err != nil || resp.Success {
+		t.Fatalf("未登记插件的心跳应返回失败: %v %v", resp, err)
+	}
+	if err := os.WriteFile(knownPluginsFilePath(th.config.DiscoveryFile), []byte(`["`+plugin.ID+`"]`), 0600); err != nil {
+		t.Fatalf("写入插件列表文件失败: %v", err)
+	}
+	th.loadKnownPlugins()
+
+	if err := plugin.registerToHost(); err != nil {
+		t.Fatalf("插件重新注册失败: %v", err)
+	}
+	adopted, err := th.waitPluginReady(plugin.ID, testPluginReadyLimit)
+	if err != nil {
+		t.Fatalf("等待被接管的插件就绪失败: %v", err)
+	}
+	if !adopted.Adopted || adopted.AutoRestart {
+		t.Fatalf("被接管的插件 adopted=%v auto_restart=%v，期望 true、false", adopted.Adopted, adopted.AutoRestart)
+	}
+	if err := th.UpgradePlugin(plugin.ID, "new-version"); !errors.Is(err, ErrPluginAdopted) {
+		t.Fatalf("升级被接管的插件返回 %v，期望 ErrPluginAdopted", err)
+	}
+	if err := th.SetRestartPolicy(plugin.ID, true, 3); !errors.Is(err, ErrPluginAdopted) {
+		t.Fatalf("为被接管的插件启用自动重启返回 %v，期望 ErrPluginAdopted", err)
+	}
+
+	if err := th.StopPlugin(plugin.ID); err != nil {
+		t.Fatalf("停止被接管的插件失败: %v", err)
+	}
+	select {
+	case <-plugin.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("被接管的插件在停止后未退出")
+	}
+}
+
+// TestInterPluginCallTargetStopping 目标插件在状态检查之后、转发之前被停止时返回 TARGET_PLUGIN_NOT_RUNNING，不panic
+func TestInterPluginCallTargetStopping(t *testing.T) {
+	th := newTestHost(t)
+	caller := connectTestPlugin(t, th, "CallerPlugin", nil)
+	target := connectTestPlugin(t, th, "TargetPlugin", func(p *Plugin) {
+		p.RegisterFunction("Echo", returnString("ok"))
+	})
+
+	// 模拟停止流程已清空客户端、尚未更新状态的时刻
+	info, _ := th.registry.Get(target.ID)
+	info.closeConnection()
+
+	resp, err := caller.CallOtherPluginCtx(context.Background(), target.ID, "Echo", nil)
+	if err != nil {
+		t.Fatalf("插件间调用失败: %v", err)
+	}
+	if resp.Success || resp.ErrorCode != "TARGET_PLUGIN_NOT_RUNNING" {
+		t.Fatalf("响应为 success=%v error_code=%q，期望 TARGET_PLUGIN_NOT_RUNNING", resp.Success, resp.ErrorCode)
+	}
+}
+
+// TestInterPluginCallDuringStop 插件间调用与停止目标插件并发进行时，调用返回成功或明确的错误码，不panic
+func TestInterPluginCallDuringStop(t *testing.T) {
+	th := newTestHost(t)
+	caller := connectTestPlugin(t, th, "CallerPlugin", nil)
+	target := connectTestPlugin(t, th, "TargetPlugin", func(p *Plugin) {
+		p.RegisterFunction("Echo", returnString("ok"))
+	})
+
+	allowed := map[string]bool{
+		"TARGET_PLUGIN_NOT_RUNNING": true,
+		"TARGET_PLUGIN_NOT_FOUND":   true,
+		ErrorCodePluginStopped:      true,
+		"INTER_PLUGIN_CALL_ERROR":   true,
+	}
+	done := make(chan struct{})
+	failures := make(chan string, 1)
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			resp, err := caller.CallOtherPluginCtx(context.Background(), target.ID, "Echo", nil)
+			if err != nil {
+				failures <- err.Error()
+				return
+			}
+			if !resp.Success && !allowed[resp.ErrorCode] {
+				failures <- resp.ErrorCode + ": " + resp.Message
+				return
+			}
+		}
+	}()
+
+	if err := th.StopPlugin(target.ID); err != nil {
+		t.Fatalf("停止插件失败: %v", err)
+	}
+	<-done
+	select {
+	case failure := <-failures:
+		t.Fatalf("停止期间的插件间调用返回了意外结果: %s", failure)
+	default:
+	}
+}
+
+// TestInterPluginCallerIdentity 插件间调用的调用方必须携带注册时签发的凭证，冒用其他插件ID或未登记的ID被拒绝
+func TestInterPluginCallerIdentity(t *testing.T) {
+	th := newTestHost(t)
+	trusted := connectTestPlugin(t, th, "TrustedPlugin", nil)
+	restricted := connectTestPlugin(t, th, "RestrictedPlugin", nil)
+	target := connectTestPlugin(t, th, "TargetPlugin", func(p *Plugin) {
+		p.RegisterFunction("Echo", returnString("ok"))
+	})
+	th.SetInterPluginPolicy(restricted.ID, nil)
+
+	// 绕过 CallOtherPluginCtx，手工构造自称为其他插件的请求
+	spoof := func(sourceID string, token string) *proto.CallResponse {
+		metadata := map[string]string{"plugin_id": sourceID, "target_plugin_id": target.ID}
+		if token != "" {
+			metadata[metadataCallToken] = token
+		}
+		resp, err := restricted.HostClient.CallHostFunction(context.Background(), &proto.CallRequest{
+			FunctionName: "Echo",
+			RequestId:    "spoof",
+			Metadata:     metadata,
+		})
+		if err != nil {
+			t.Fatalf("发送插件间调用失败: %v", err)
+		}
+		return resp
+	}
+
+	ownToken, _ := restricted.callToken.Load().(string)
+	cases := []struct {
+		name  string
+		resp  *proto.CallResponse
+		code  string
+		allow bool
+	}{
+		{"冒用其他插件ID", spoof(trusted.ID, ""), "CALLER_NOT_VERIFIED", false},
+		{"冒用其他插件ID并携带自己的凭证", spoof(trusted.ID, ownToken), "CALLER_NOT_VERIFIED", false},
+		{"未登记的插件ID", spoof("unknown-plugin", ownToken), "CALLER_NOT_VERIFIED", false},
+		{"受限插件以自身身份调用", spoof(restricted.ID, ownToken), "INTER_PLUGIN_DENIED", false},
+	}
+	for _, c := range cases {
+		if c.resp.Success || c.resp.ErrorCode != c.code {
+			t.Fatalf("%s: 响应为 success=%v error_code=%q，期望 %q", c.name, c.resp.Success, c.resp.ErrorCode, c.code)
+		}
+	}
+
+	// 正常的插件间调用不受影响
+	resp, err := trusted.CallOtherPluginCtx(context.Background(), target.ID, "Echo", nil)
+	if err != nil || !resp.Success {
+		t.Fatalf("合法的插件间调用失败: %v %v", resp, err)
+	}
+}
+
+// TestInterPluginCallLoop 插件间循环调用（A → B → A、A → A）返回 CALL_LOOP_DETECTED 并给出调用链，无循环的多跳调用正常完成
+func TestInterPluginCallLoop(t *testing.T) {
+	th := newTestHost(t)
+
+	// forward 返回转发调用的函数：调用目标插件的函数，并把响应的错误码和消息作为结果返回
+	var pluginA, pluginB, pluginC *Plugin
+	forward := func(self **Plugin, target **Plugin, function string) PluginFunction {
+		return func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+			resp, err := (*self).CallOtherPluginCtx(ctx, (*target).ID, function, nil)
+			if err != nil {
+				return nil, err
+			}
+			if resp.Success {
+				return resp.Result, nil
+			}
+			return stringResult(resp.ErrorCode + " " + resp.Message), nil
+		}
+	}
+	pluginA = connectTestPlugin(t, th, "LoopPluginA", func(p *Plugin) {
+		p.RegisterFunction("Ping", forward(&pluginA, &pluginB, "Pong"))
+		p.RegisterFunction("Self", forward(&pluginA, &pluginA, "Self"))
+		p.RegisterFunction("Chain", forward(&pluginA, &pluginB, "Relay"))
+	})
+	pluginB = connectTestPlugin(t, th, "LoopPluginB", func(p *Plugin) {
+		p.RegisterFunction("Pong", forward(&pluginB, &pluginA, "Ping"))
+		p.RegisterFunction("Relay", forward(&pluginB, &pluginC, "Echo"))
+	})
+	pluginC = connectTestPlugin(t, th, "LoopPluginC", func(p *Plugin) {
+		p.RegisterFunction("Echo", returnString("ok"))
+	})
+
+	cases := []struct {
+		name     string
+		function string
+		want     string
+	}{
+		{"A → B → A", "Ping", "CALL_LOOP_DETECTED 检测到循环调用: " + strings.Join([]string{pluginA.ID, pluginB.ID, pluginA.ID}, " -> ")},
+		{"A → A", "Self", "CALL_LOOP_DETECTED 检测到循环调用: " + pluginA.ID + " -> " + pluginA.ID},
+		{"A → B → C", "Chain", "ok"},
+	}
+	for _, c := range cases {
+		resp, err := th.CallPluginFunction(pluginA.ID, c.function, nil)
+		if err != nil || !resp.Success {
+			t.Fatalf("%s: 调用失败: %v %v", c.name, resp, err)
+		}
+		if resp.Result.Value != c.want {
+			t.Fatalf("%s: 结果为 %q，期望 %q", c.name, resp.Result.Value, c.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package wwplugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwwlkj/wwhyplugin/proto"
+)
+
+// envTestPluginProcess 设置后测试程序作为插件子进程运行，供需要真实插件进程的测试使用
+const envTestPluginProcess = "WWPLUGIN_TEST_PLUGIN_PROCESS"
+
+// TestMain 由主机作为插件进程启动时运行测试插件，否则正常执行测试
+func TestMain(m *testing.M) {
+	if os.Getenv(envTestPluginProcess) != "" {
+		plugin := NewPlugin(DefaultPluginConfig("ProcessPlugin", "1.0.0", "测试插件进程"))
+		plugin.RegisterFunction("Version", returnString("1.0.0"))
+		if err := RunPluginMain(plugin, os.Args); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// finishWithin 在限定时间内执行 fn，超时视为死锁
+func finishWithin(t *testing.T, timeout time.Duration, name string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("%s 未在 %v 内返回", name, timeout)
+	}
+}
+
+// TestHostStartTwice 重复启动主机返回 ErrAlreadyStarted
+func TestHostStartTwice(t *testing.T) {
+	th := newTestHost(t)
+
+	if err := th.Start(); !errors.Is(err, ErrAlreadyStarted) {
+		t.Fatalf("第二次 Start 返回 %v，期望 ErrAlreadyStarted", err)
+	}
+}
+
+// TestStopTwice 主机和插件重复停止不panic、不死锁
+func TestStopTwice(t *testing.T) {
+	th := newTestHost(t)
+	plugin := connectTestPlugin(t, th, "StopTwicePlugin", nil)
+
+	if _, err := plugin.StartAsync(); !errors.Is(err, ErrAlreadyStarted) {
+		t.Fatalf("第二次 StartAsync 返回 %v，期望 ErrAlreadyStarted", err)
+	}
+
+	finishWithin(t, 10*time.Second, "Plugin.Stop", func() {
+		plugin.Stop()
+		plugin.Stop()
+	})
+	finishWithin(t, 10*time.Second, "PluginHost.Stop", func() {
+		th.Close()
+		th.Stop()
+	})
+}
+
+// TestStopPluginCancelsInFlightCall 停止插件时进行中的调用立即以 ErrPluginStopped 结束，不等待调用超时
+func TestStopPluginCancelsInFlightCall(t *testing.T) {
+	th := newTestHost(t)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	plugin := connectTestPlugin(t, th, "BlockingPlugin", func(p *Plugin) {
+		p.RegisterFunction("Block", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+			close(entered)
+			<-release
+			return nil, nil
+		})
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := th.CallPluginFunction(plugin.ID, "Block", nil)
+		result <- err
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("插件函数未被调用")
+	}
+
+	if err := th.StopPlugin(plugin.ID); err != nil {
+		t.Fatalf("停止插件失败: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrPluginStopped) {
+			t.Fatalf("调用返回 %v，期望 ErrPluginStopped", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("停止插件后进行中的调用未及时返回")
+	}
+}
+
+// writeManifest 在可执行文件旁写入插件清单
+func writeManifest(t *testing.T, executablePath string, version string) {
+	t.Helper()
+	manifest := `{"name": "ProcessPlugin", "version": "` + version + `", "functions": ["Version"]}`
+	if err := os.WriteFile(manifestPath(executablePath), []byte(manifest), 0644); err != nil {
+		t.Fatalf("写入插件清单失败: %v", err)
+	}
+}
+
+// TestUpgradePluginRollback 新版本无法启动时升级返回错误，并以旧版本重新启动插件
+func TestUpgradePluginRollback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试依赖符号链接和执行权限位")
+	}
+	t.Setenv(envTestPluginProcess, "1")
+
+	// 子进程需要通过TCP连接主机
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	config := DefaultHostConfig()
+	config.DebugMode = false
+	config.Listener = listener
+	config.PreferManifest = true
+	config.AutoRestartPlugin = false
+	host, err := NewPluginHost(config)
+	if err != nil {
+		t.Fatalf("创建主机失败: %v", err)
+	}
+	if err := host.Start(); err != nil {
+		t.Fatalf("启动主机失败: %v", err)
+	}
+	defer host.Stop()
+
+	// 旧版本：指向测试程序自身的插件；新版本：有清单但没有执行权限的文件
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "plugin-v1")
+	if err := os.Symlink(os.Args[0], oldPath); err != nil {
+		t.Fatalf("创建旧版本插件失败: %v", err)
+	}
+	writeManifest(t, oldPath, "1.0.0")
+	newPath := filepath.Join(dir, "plugin-v1.1")
+	if err := os.WriteFile(newPath, []byte("not a plugin"), 0644); err != nil {
+		t.Fatalf("创建新版本插件失败: %v", err)
+	}
+	writeManifest(t, newPath, "1.1.0")
+
+	info, err := host.LoadPlugin(oldPath)
+	if err != nil {
+		t.Fatalf("加载插件失败: %v", err)
+	}
+	if err := host.StartPlugin(info.ID); err != nil {
+		t.Fatalf("启动插件失败: %v", err)
+	}
+	if _, err := host.waitPluginReady(info.ID, 15*time.Second); err != nil {
+		t.Fatalf("等待插件就绪失败: %v", err)
+	}
+
+	err = host.UpgradePlugin(info.ID, newPath)
+	if err == nil || !strings.Contains(err.Error(), "已回滚") {
+		t.Fatalf("升级返回 %v，期望启动失败并已回滚", err)
+	}
+
+	plugin, err := host.waitPluginReady(info.ID, 15*time.Second)
+	if err != nil {
+		t.Fatalf("回滚后等待插件就绪失败: %v", err)
+	}
+	if plugin.ExecutablePath != oldPath || plugin.Version != "1.0.0" {
+		t.Fatalf("回滚后插件为 %s（%s），期望旧版本 %s（1.0.0）", plugin.ExecutablePath, plugin.Version, oldPath)
+	}
+	resp, err := host.CallPluginFunction(info.ID, "Version", nil)
+	if err != nil || !resp.Success || resp.Result.Value != "1.0.0" {
+		t.Fatalf("回滚后调用旧版本插件失败: %v %v", resp, err)
+	}
+}
@@ -0,0 +1,25 @@
+// Package wwplugin 插件ID生成
+// 默认使用 "名称-UUID" 作为插件ID，避免同名插件在同一秒启动时ID冲突
+package wwplugin
+
+import (
+	"crypto/rand" // 加密随机数，用于生成UUID
+	"fmt"         // 格式化输出，用于拼接UUID文本
+)
+
+// DefaultIDGenerator 默认插件ID生成器
+// 生成 "<插件名称>-<UUID v4>"，名称便于日志辨认，UUID保证唯一
+func DefaultIDGenerator(config *PluginConfig) string {
+	return fmt.Sprintf("%s-%s", config.Name, newUUID())
+}
+
+// newUUID 生成随机UUID（版本4）
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("生成UUID失败: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
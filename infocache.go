@@ -0,0 +1,87 @@
+// Package wwplugin 插件信息缓存
+// 缓存 --info 查询结果，避免目录扫描、界面刷新等场景反复启动插件进程读取元数据
+package wwplugin
+
+import (
+	"os"            // 操作系统接口，用于读取可执行文件状态
+	"path/filepath" // 文件路径处理，用于统一缓存键
+	"sync"          // 同步原语，保护缓存
+	"time"          // 时间处理，记录可执行文件修改时间
+)
+
+// pluginInfoEntry 插件信息缓存项
+type pluginInfoEntry struct {
+	info    *PluginBasicInfo // 插件信息
+	modTime time.Time        // 读取时可执行文件的修改时间
+	size    int64            // 读取时可执行文件的大小
+}
+
+// pluginInfoCache 按可执行文件路径缓存插件信息
+// 可执行文件的修改时间或大小变化后缓存失效；零值可直接使用
+type pluginInfoCache struct {
+	entries map[string]pluginInfoEntry
+	mutex   sync.Mutex
+}
+
+// get 获取可执行文件未变化时的缓存信息（副本）
+func (c *pluginInfoCache) get(executablePath string, stat os.FileInfo) (*PluginBasicInfo, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[infoCacheKey(executablePath)]
+	if !exists || !entry.modTime.Equal(stat.ModTime()) || entry.size != stat.Size() {
+		return nil, false
+	}
+	return copyPluginBasicInfo(entry.info), true
+}
+
+// put 缓存插件信息（保存副本）
+func (c *pluginInfoCache) put(executablePath string, stat os.FileInfo, info *PluginBasicInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]pluginInfoEntry)
+	}
+	c.entries[infoCacheKey(executablePath)] = pluginInfoEntry{
+		info:    copyPluginBasicInfo(info),
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+	}
+}
+
+// remove 移除可执行文件的缓存信息
+func (c *pluginInfoCache) remove(executablePath string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, infoCacheKey(executablePath))
+}
+
+// infoCacheKey 缓存键 - 可执行文件的绝对路径，同一文件的不同写法共用缓存
+func infoCacheKey(executablePath string) string {
+	if abs, err := filepath.Abs(executablePath); err == nil {
+		return abs
+	}
+	return executablePath
+}
+
+// copyPluginBasicInfo 复制插件信息，避免调用方修改影响缓存
+func copyPluginBasicInfo(info *PluginBasicInfo) *PluginBasicInfo {
+	result := *info
+	result.Capabilities = append([]string(nil), info.Capabilities...)
+	result.Functions = append([]string(nil), info.Functions...)
+	if info.FunctionTimeouts != nil {
+		result.FunctionTimeouts = make(map[string]int64, len(info.FunctionTimeouts))
+		for name, timeout := range info.FunctionTimeouts {
+			result.FunctionTimeouts[name] = timeout
+		}
+	}
+	return &result
+}
+
+// RefreshPluginInfo 丢弃缓存并重新读取插件信息
+// 可执行文件被原地替换但修改时间和大小都未变化时（如部分部署工具会保留时间戳）使用
+func (ph *PluginHost) RefreshPluginInfo(executablePath string) (*PluginBasicInfo, error) {
+	ph.infoCache.remove(executablePath)
+	return ph.GetPluginInfo(executablePath)
+}
@@ -0,0 +1,144 @@
+// Package wwplugin 进程内插件
+// 进程内插件与主机运行在同一进程中，调用直接分发到处理函数，不启动子进程也不经过gRPC
+package wwplugin
+
+import (
+	"context" // 上下文控制，用于传递调用上下文
+	"errors"  // 错误处理，用于判断错误类型
+	"fmt"     // 格式化输出，用于错误信息
+	"time"    // 时间处理，用于记录启动和心跳时间
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+	"google.golang.org/grpc"             // gRPC框架，用于实现客户端接口
+	"google.golang.org/grpc/codes"       // gRPC状态码
+	"google.golang.org/grpc/status"      // gRPC状态，用于返回不支持的操作
+)
+
+// RegisterInProcessPlugin 注册进程内插件
+// 插件注册后即为运行状态，主机对其的函数调用（包括插件间调用）直接分发到 handler
+// 进程内插件没有心跳，不参与心跳超时检测，也不支持升级和消息流
+func (ph *PluginHost) RegisterInProcessPlugin(info *PluginBasicInfo, handler InProcessHandler) (*PluginInfo, error) {
+	if info == nil || info.Name == "" {
+		return nil, fmt.Errorf("进程内插件缺少插件名称")
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("进程内插件 %s 缺少处理函数", info.Name)
+	}
+
+	pluginID := info.ID
+	if pluginID == "" {
+		pluginID = "plugin-" + newUUID()
+	}
+	if _, exists := ph.registry.Get(pluginID); exists {
+		return nil, fmt.Errorf("插件 %s 已存在", pluginID)
+	}
+
+	now := time.Now()
+	plugin := &PluginInfo{
+		ID:            pluginID,
+		Name:          info.Name,
+		Version:       info.Version,
+		Description:   info.Description,
+		Capabilities:  info.Capabilities,
+		Functions:     info.Functions,
+		Status:        StatusStopped,
+		StartTime:     now,
+		LastHeartbeat: now,
+		InProcess:     true,
+
+		FunctionTimeouts: functionTimeouts(info.FunctionTimeouts),
+	}
+	plugin.setConnection(nil, &inProcessClient{plugin: plugin, handler: handler})
+	plugin.resetCallContext(ph.ctx)
+
+	ph.registry.Register(plugin)
+	ph.setPluginStatus(plugin, StatusRunning)
+
+	ph.logger.Info("✅ 已注册进程内插件", "plugin_id", pluginID, "plugin_name", info.Name)
+	return plugin, nil
+}
+
+// inProcessClient 进程内插件客户端
+// 实现 proto.PluginServiceClient，使进程内插件与子进程插件共用调用路径
+type inProcessClient struct {
+	plugin  *PluginInfo
+	handler InProcessHandler
+}
+
+// CallPluginFunction 直接调用处理函数，panic和错误转换为失败响应
+func (c *inProcessClient) CallPluginFunction(ctx context.Context, req *proto.CallRequest, _ ...grpc.CallOption) (resp *proto.CallResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = &proto.CallResponse{
+				Success:   false,
+				Message:   fmt.Sprintf("%v: %v", ErrFunctionPanic, r),
+				ErrorCode: ErrorCodeFunctionPanic,
+				RequestId: req.RequestId,
+			}, nil
+		}
+	}()
+
+	result, err := c.handler(withRequest(ctx, req.RequestId, req.Metadata), req.FunctionName, req.Parameters)
+	if err == nil {
+		err = validateResult(result)
+	}
+	if err != nil {
+		errorCode := "FUNCTION_ERROR"
+		if errors.Is(err, ErrMarshal) {
+			errorCode = ErrorCodeMarshal
+		}
+		return &proto.CallResponse{
+			Success:   false,
+			Message:   err.Error(),
+			ErrorCode: errorCode,
+			RequestId: req.RequestId,
+		}, nil
+	}
+
+	return &proto.CallResponse{
+		Success:   true,
+		Message:   "调用成功",
+		Result:    result,
+		RequestId: req.RequestId,
+	}, nil
+}
+
+// ReceiveMessages 进程内插件不支持消息流
+func (c *inProcessClient) ReceiveMessages(ctx context.Context, _ ...grpc.CallOption) (proto.PluginService_ReceiveMessagesClient, error) {
+	return nil, status.Errorf(codes.Unimplemented, "进程内插件 %s 不支持消息流", c.plugin.ID)
+}
+
+// GetPluginStatus 返回进程内插件的状态
+func (c *inProcessClient) GetPluginStatus(ctx context.Context, req *proto.StatusRequest, _ ...grpc.CallOption) (*proto.StatusResponse, error) {
+	return &proto.StatusResponse{
+		Status:          string(c.plugin.Status),
+		Uptime:          time.Since(c.plugin.StartTime).String(),
+		ActiveFunctions: c.plugin.Functions,
+	}, nil
+}
+
+// Shutdown 进程内插件没有进程需要退出，直接确认
+func (c *inProcessClient) Shutdown(ctx context.Context, req *proto.ShutdownRequest, _ ...grpc.CallOption) (*proto.ShutdownResponse, error) {
+	return &proto.ShutdownResponse{
+		Success: true,
+		Message: "进程内插件已停止",
+	}, nil
+}
+
+// ConfigureHeartbeat 进程内插件没有心跳
+func (c *inProcessClient) ConfigureHeartbeat(ctx context.Context, req *proto.HeartbeatConfigRequest, _ ...grpc.CallOption) (*proto.HeartbeatConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "进程内插件 %s 没有心跳", c.plugin.ID)
+}
+
+// Echo 原样返回请求内容
+func (c *inProcessClient) Echo(ctx context.Context, req *proto.EchoRequest, _ ...grpc.CallOption) (*proto.EchoResponse, error) {
+	return &proto.EchoResponse{Payload: req.Payload}, nil
+}
+
+// PushConfig 进程内插件直接读取 PluginInfo.PluginConfigData，无需推送
+func (c *inProcessClient) PushConfig(ctx context.Context, req *proto.ConfigRequest, _ ...grpc.CallOption) (*proto.ConfigResponse, error) {
+	return &proto.ConfigResponse{
+		Success: true,
+		Message: "进程内插件直接读取配置",
+	}, nil
+}
@@ -0,0 +1,276 @@
+// Package wwplugin JSON-RPC 2.0 桥接
+// 将 JSON-RPC 请求映射为插件函数调用，供浏览器扩展、编辑器等无法使用gRPC的客户端驱动插件
+package wwplugin
+
+import (
+	"bytes"         // 字节处理，用于判断批量请求
+	"encoding/json" // JSON编解码，用于解析请求和参数转换
+	"fmt"           // 格式化输出，用于参数命名和错误信息
+	"io"            // 输入输出，用于读取请求体
+	"net/http"      // HTTP服务，用于提供JSON-RPC端点
+	"sort"          // 排序，用于按名称稳定排列命名参数
+	"strconv"       // 字符串转换，用于解析数值类型的返回值
+	"strings"       // 字符串处理，用于拆分方法名
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// JSON-RPC 2.0 错误码
+const (
+	jsonrpcParseError     = -32700 // 请求体不是合法的JSON
+	jsonrpcInvalidRequest = -32600 // 请求不符合 JSON-RPC 2.0 格式
+	jsonrpcMethodNotFound = -32601 // 方法格式错误或插件未提供该函数
+	jsonrpcInvalidParams  = -32602 // 参数既不是数组也不是对象
+	jsonrpcCallFailed     = -32000 // 插件调用失败 - error.data.error_code 为框架错误码
+)
+
+// jsonrpcMaxBodyBytes JSON-RPC 请求体大小上限
+const jsonrpcMaxBodyBytes = 4 << 20
+
+// jsonrpcRequest JSON-RPC 2.0 请求
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"` // 协议版本，必须为 "2.0"
+	ID      json.RawMessage `json:"id"`      // 请求ID，缺省时为通知，不返回响应
+	Method  string          `json:"method"`  // 方法名，格式为 "插件ID.函数名"
+	Params  json.RawMessage `json:"params"`  // 参数，数组（按位置）或对象（按名称）
+}
+
+// jsonrpcResponse JSON-RPC 2.0 响应
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+// jsonrpcError JSON-RPC 2.0 错误对象
+type jsonrpcError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// JSONRPCHandler 返回 JSON-RPC 2.0 端点的HTTP处理器
+// 方法名格式为 "插件ID.函数名"，映射到 CallPluginFunction；支持批量请求和通知。
+// 处理器不做认证，挂载到对外暴露的HTTP服务时应由调用方加上访问控制
+func (ph *PluginHost) JSONRPCHandler() http.Handler {
+	return http.HandlerFunc(ph.serveJSONRPC)
+}
+
+// serveJSONRPC 处理 JSON-RPC 请求
+func (ph *PluginHost) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "JSON-RPC 只接受POST请求", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, jsonrpcMaxBodyBytes))
+	if err != nil {
+		writeJSONRPC(w, jsonrpcErrorResponse(nil, jsonrpcParseError, fmt.Sprintf("读取请求失败: %v", err)))
+		return
+	}
+
+	// 批量请求逐个处理，通知不产生响应
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeJSONRPC(w, jsonrpcErrorResponse(nil, jsonrpcParseError, fmt.Sprintf("解析请求失败: %v", err)))
+			return
+		}
+		if len(batch) == 0 {
+			writeJSONRPC(w, jsonrpcErrorResponse(nil, jsonrpcInvalidRequest, "批量请求为空"))
+			return
+		}
+
+		responses := make([]*jsonrpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp := ph.handleJSONRPC(raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSONRPC(w, responses)
+		return
+	}
+
+	resp := ph.handleJSONRPC(body)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSONRPC(w, resp)
+}
+
+// handleJSONRPC 处理单个 JSON-RPC 请求，通知返回nil
+func (ph *PluginHost) handleJSONRPC(raw json.RawMessage) *jsonrpcResponse {
+	var req jsonrpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return jsonrpcErrorResponse(nil, jsonrpcParseError, fmt.Sprintf("解析请求失败: %v", err))
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return jsonrpcErrorResponse(req.ID, jsonrpcInvalidRequest, "请求不符合 JSON-RPC 2.0 格式")
+	}
+	notification := len(req.ID) == 0
+
+	// 函数名不含"."，插件ID可能包含，从最后一个"."拆分
+	dot := strings.LastIndex(req.Method, ".")
+	if dot <= 0 || dot == len(req.Method)-1 {
+		if notification {
+			return nil
+		}
+		return jsonrpcErrorResponse(req.ID, jsonrpcMethodNotFound, fmt.Sprintf("方法名格式应为 插件ID.函数名: %s", req.Method))
+	}
+	pluginID, functionName := req.Method[:dot], req.Method[dot+1:]
+
+	params, err := jsonrpcParams(req.Params)
+	if err != nil {
+		if notification {
+			return nil
+		}
+		return jsonrpcErrorResponse(req.ID, jsonrpcInvalidParams, err.Error())
+	}
+
+	callResp, err := ph.CallPluginFunction(pluginID, functionName, params)
+	if notification {
+		return nil
+	}
+	if err != nil {
+		return jsonrpcErrorResponse(req.ID, jsonrpcCallFailed, err.Error())
+	}
+	if !callResp.Success {
+		code := jsonrpcCallFailed
+		if callResp.ErrorCode == "FUNCTION_NOT_FOUND" {
+			code = jsonrpcMethodNotFound
+		}
+		resp := jsonrpcErrorResponse(req.ID, code, callResp.Message)
+		if callResp.ErrorCode != "" {
+			resp.Error.Data = map[string]string{"error_code": callResp.ErrorCode}
+		}
+		return resp
+	}
+
+	return &jsonrpcResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  jsonrpcResult(callResp.Result),
+	}
+}
+
+// jsonrpcParams 将 JSON-RPC 参数转换为调用参数
+// 数组按位置命名为 arg0、arg1...；对象按名称排序后逐个转换
+func jsonrpcParams(raw json.RawMessage) ([]*proto.Parameter, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return []*proto.Parameter{}, nil
+	}
+
+	switch raw[0] {
+	case '[':
+		var values []json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("解析参数失败: %v", err)
+		}
+		params := make([]*proto.Parameter, 0, len(values))
+		for i, value := range values {
+			params = append(params, jsonValueParameter(fmt.Sprintf("arg%d", i), value))
+		}
+		return params, nil
+	case '{':
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("解析参数失败: %v", err)
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		params := make([]*proto.Parameter, 0, len(values))
+		for _, name := range names {
+			params = append(params, jsonValueParameter(name, values[name]))
+		}
+		return params, nil
+	default:
+		return nil, fmt.Errorf("参数必须是数组或对象")
+	}
+}
+
+// jsonValueParameter 按JSON值的类型构造参数
+// 字符串、整数、浮点数、布尔值转换为对应的基本类型，其余（对象、数组、null）保留为JSON类型
+func jsonValueParameter(name string, value json.RawMessage) *proto.Parameter {
+	value = bytes.TrimSpace(value)
+	param := &proto.Parameter{Name: name, Type: proto.ParameterType_JSON, Value: string(value)}
+
+	switch {
+	case len(value) == 0:
+	case value[0] == '"':
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			param.Type, param.Value = proto.ParameterType_STRING, s
+		}
+	case bytes.Equal(value, []byte("true")), bytes.Equal(value, []byte("false")):
+		param.Type = proto.ParameterType_BOOL
+	case value[0] == '-' || (value[0] >= '0' && value[0] <= '9'):
+		if _, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			param.Type = proto.ParameterType_INT
+		} else {
+			param.Type = proto.ParameterType_FLOAT
+		}
+	}
+	return param
+}
+
+// jsonrpcResult 将调用结果转换为JSON值
+// 数值、布尔和JSON类型还原为对应的JSON值，无法还原时与其余类型一样作为字符串返回
+func jsonrpcResult(result *proto.Parameter) json.RawMessage {
+	if result == nil {
+		return json.RawMessage("null")
+	}
+
+	switch result.Type {
+	case proto.ParameterType_INT:
+		if _, err := strconv.ParseInt(result.Value, 10, 64); err == nil {
+			return json.RawMessage(result.Value)
+		}
+	case proto.ParameterType_FLOAT:
+		if f, err := strconv.ParseFloat(result.Value, 64); err == nil {
+			if data, err := json.Marshal(f); err == nil {
+				return data
+			}
+		}
+	case proto.ParameterType_BOOL:
+		if b, err := strconv.ParseBool(result.Value); err == nil {
+			return json.RawMessage(strconv.FormatBool(b))
+		}
+	case proto.ParameterType_JSON:
+		if json.Valid([]byte(result.Value)) {
+			return json.RawMessage(result.Value)
+		}
+	}
+
+	data, _ := json.Marshal(result.Value)
+	return data
+}
+
+// jsonrpcErrorResponse 构造错误响应，无法确定请求ID时ID为null
+func jsonrpcErrorResponse(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &jsonrpcError{Code: code, Message: message},
+	}
+}
+
+// writeJSONRPC 写出 JSON-RPC 响应，协议层错误也使用HTTP 200
+func writeJSONRPC(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
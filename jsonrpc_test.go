@@ -0,0 +1,124 @@
+package wwplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wwwlkj/wwhyplugin/proto"
+)
+
+// TestJSONRPCHandler JSON-RPC 2.0 端点的调用、批量、通知和错误码映射
+func TestJSONRPCHandler(t *testing.T) {
+	th := newTestHost(t)
+
+	var notified int32
+	plugin := connectTestPlugin(t, th, "RPCPlugin", func(p *Plugin) {
+		p.RegisterFunction("Add", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+			sum := 0
+			for _, param := range params {
+				n, err := strconv.Atoi(param.Value)
+				if err != nil {
+					return nil, fmt.Errorf("参数 %s 不是整数", param.Name)
+				}
+				sum += n
+			}
+			return &proto.Parameter{Name: "result", Type: proto.ParameterType_INT, Value: strconv.Itoa(sum)}, nil
+		})
+		p.RegisterFunction("Notify", func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+			atomic.AddInt32(&notified, 1)
+			return nil, nil
+		})
+	})
+	call := func(id, method, params string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"method":"%s.%s","params":%s}`, id, plugin.ID, method, params)
+	}
+	notify := fmt.Sprintf(`{"jsonrpc":"2.0","method":"%s.Notify"}`, plugin.ID)
+
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		want   []jsonrpcResponse // 期望的响应，批量请求按顺序对应；Result 只比较非空的项
+	}{
+		{"调用", call("1", "Add", "[1, 2]"), http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Result: json.RawMessage("3")}}},
+		{"命名参数", call(`"a"`, "Add", `{"x": 4, "y": 5}`), http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage(`"a"`), Result: json.RawMessage("9")}}},
+		{"批量", "[" + call("1", "Add", "[1]") + "," + notify + "," + call("2", "Missing", "[]") + "]", http.StatusOK,
+			[]jsonrpcResponse{
+				{ID: json.RawMessage("1"), Result: json.RawMessage("1")},
+				{ID: json.RawMessage("2"), Error: &jsonrpcError{Code: jsonrpcMethodNotFound}},
+			}},
+		{"通知", notify, http.StatusNoContent, nil},
+		{"解析错误", `{"jsonrpc":`, http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("null"), Error: &jsonrpcError{Code: jsonrpcParseError}}}},
+		{"请求格式错误", `{"jsonrpc":"1.0","id":1,"method":"x.y"}`, http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Error: &jsonrpcError{Code: jsonrpcInvalidRequest}}}},
+		{"函数不存在", call("1", "Missing", "[]"), http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Error: &jsonrpcError{Code: jsonrpcMethodNotFound}}}},
+		{"方法名格式错误", `{"jsonrpc":"2.0","id":1,"method":"NoDot"}`, http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Error: &jsonrpcError{Code: jsonrpcMethodNotFound}}}},
+		{"参数无效", call("1", "Add", "5"), http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Error: &jsonrpcError{Code: jsonrpcInvalidParams}}}},
+		{"调用失败", call("1", "Add", `["x"]`), http.StatusOK,
+			[]jsonrpcResponse{{ID: json.RawMessage("1"), Error: &jsonrpcError{Code: jsonrpcCallFailed}}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			th.JSONRPCHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(c.body)))
+			if recorder.Code != c.status {
+				t.Fatalf("HTTP状态码为 %d，期望 %d: %s", recorder.Code, c.status, recorder.Body)
+			}
+			if c.want == nil {
+				return
+			}
+
+			var got []jsonrpcResponse
+			body := recorder.Body.Bytes()
+			if strings.HasPrefix(c.body, "[") {
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("解析批量响应失败: %v: %s", err, body)
+				}
+			} else {
+				var single jsonrpcResponse
+				if err := json.Unmarshal(body, &single); err != nil {
+					t.Fatalf("解析响应失败: %v: %s", err, body)
+				}
+				got = []jsonrpcResponse{single}
+			}
+
+			if len(got) != len(c.want) {
+				t.Fatalf("收到 %d 个响应，期望 %d: %s", len(got), len(c.want), body)
+			}
+			for i, want := range c.want {
+				resp := got[i]
+				if resp.JSONRPC != "2.0" || string(resp.ID) != string(want.ID) {
+					t.Fatalf("响应 %d 的版本或ID错误: %s", i, body)
+				}
+				if want.Error != nil {
+					if resp.Error == nil || resp.Error.Code != want.Error.Code {
+						t.Fatalf("响应 %d 期望错误码 %d: %s", i, want.Error.Code, body)
+					}
+					continue
+				}
+				if resp.Error != nil || string(resp.Result) != string(want.Result) {
+					t.Fatalf("响应 %d 期望结果 %s: %s", i, want.Result, body)
+				}
+			}
+		})
+	}
+
+	// 单独的通知和批量中的通知各调用一次函数
+	if got := atomic.LoadInt32(&notified); got != 2 {
+		t.Fatalf("通知调用了 %d 次函数，期望 2 次", got)
+	}
+}
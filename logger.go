@@ -0,0 +1,122 @@
+// Package wwplugin 日志接口
+// 主机和插件通过 Logger 输出日志，使用者可以接入自己的日志系统
+package wwplugin
+
+import (
+	"fmt"     // 格式化输出，用于拼接日志字段
+	"log"     // 日志记录，默认实现基于标准库
+	"strings" // 字符串处理，用于构建日志行
+)
+
+// Logger 日志接口
+// fields 为键值对交替排列的附加字段，如 "plugin_id", id, "error", err
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+// stdLogger 基于标准库 log 的默认日志实现
+type stdLogger struct {
+	level LogLevel // 最低输出级别
+}
+
+// NewStdLogger 创建基于标准库 log 的日志实现
+// 低于 level 的日志会被丢弃
+func NewStdLogger(level LogLevel) Logger {
+	return &stdLogger{level: level}
+}
+
+// Debug 输出调试日志
+func (l *stdLogger) Debug(msg string, fields ...interface{}) {
+	l.output(DEBUG, msg, fields)
+}
+
+// Info 输出信息日志
+func (l *stdLogger) Info(msg string, fields ...interface{}) {
+	l.output(INFO, msg, fields)
+}
+
+// Warn 输出警告日志
+func (l *stdLogger) Warn(msg string, fields ...interface{}) {
+	l.output(WARN, msg, fields)
+}
+
+// Error 输出错误日志
+func (l *stdLogger) Error(msg string, fields ...interface{}) {
+	l.output(ERROR, msg, fields)
+}
+
+// output 按 "[级别] 消息 key=value ..." 格式输出，信息级别不加前缀
+func (l *stdLogger) output(level LogLevel, msg string, fields []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	if level != INFO {
+		b.WriteString("[" + strings.ToUpper(level.String()) + "] ")
+	}
+	b.WriteString(msg)
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 < len(fields) {
+			fmt.Fprintf(&b, " %v=%v", fields[i], fields[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", fields[i])
+		}
+	}
+	log.Print(b.String())
+}
+
+// parseLogLevel 解析日志级别字符串，无法识别时返回 INFO
+func parseLogLevel(level string) LogLevel {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DEBUG
+	case "warn", "warning":
+		return WARN
+	case "error":
+		return ERROR
+	default:
+		return INFO
+	}
+}
+
+// fieldLogger 为每条日志附加固定字段的包装实现
+type fieldLogger struct {
+	base   Logger        // 实际输出日志的实现
+	fields []interface{} // 固定附加字段
+}
+
+// withFields 返回在每条日志前附加 fields 的日志实现
+func withFields(base Logger, fields ...interface{}) Logger {
+	return &fieldLogger{base: base, fields: fields}
+}
+
+// Debug 输出调试日志
+func (l *fieldLogger) Debug(msg string, fields ...interface{}) {
+	l.base.Debug(msg, l.merge(fields)...)
+}
+
+// Info 输出信息日志
+func (l *fieldLogger) Info(msg string, fields ...interface{}) {
+	l.base.Info(msg, l.merge(fields)...)
+}
+
+// Warn 输出警告日志
+func (l *fieldLogger) Warn(msg string, fields ...interface{}) {
+	l.base.Warn(msg, l.merge(fields)...)
+}
+
+// Error 输出错误日志
+func (l *fieldLogger) Error(msg string, fields ...interface{}) {
+	l.base.Error(msg, l.merge(fields)...)
+}
+
+// merge 合并固定字段与本条日志字段
+func (l *fieldLogger) merge(fields []interface{}) []interface{} {
+	merged := make([]interface{}, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	return append(merged, fields...)
+}
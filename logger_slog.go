@@ -0,0 +1,41 @@
+// Package wwplugin slog日志适配
+// 将 Logger 接口适配到标准库 log/slog，输出结构化键值日志
+package wwplugin
+
+import (
+	"log/slog" // 标准库结构化日志
+)
+
+// slogLogger 基于 log/slog 的日志实现
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 创建基于 log/slog 的日志实现
+// logger 为nil时使用 slog.Default()；日志字段直接作为 slog 的键值属性输出
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+// Debug 输出调试日志
+func (l *slogLogger) Debug(msg string, fields ...interface{}) {
+	l.logger.Debug(msg, fields...)
+}
+
+// Info 输出信息日志
+func (l *slogLogger) Info(msg string, fields ...interface{}) {
+	l.logger.Info(msg, fields...)
+}
+
+// Warn 输出警告日志
+func (l *slogLogger) Warn(msg string, fields ...interface{}) {
+	l.logger.Warn(msg, fields...)
+}
+
+// Error 输出错误日志
+func (l *slogLogger) Error(msg string, fields ...interface{}) {
+	l.logger.Error(msg, fields...)
+}
@@ -0,0 +1,43 @@
+// Package wwplugin 消息内容辅助函数
+// 通过元数据标注消息内容的类型，免去消息处理器逐个手工解析内容
+package wwplugin
+
+import (
+	"encoding/json" // JSON处理，用于序列化和解析消息内容
+	"fmt"           // 格式化输出，用于错误信息
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// 消息内容类型约定
+const (
+	MetadataContentType = "content_type"     // 消息元数据中标注内容类型的键
+	ContentTypeJSON     = "application/json" // JSON内容 - 由 SendMessageJSON 写入，DecodeMessage 解析
+)
+
+// SendMessageJSON 将 v 序列化为JSON后发送到插件
+// 消息元数据的 content_type 标注为 ContentTypeJSON，插件端可使用 DecodeMessage 解析
+func (ph *PluginHost) SendMessageJSON(pluginID string, messageType string, v interface{}) (*proto.MessageResponse, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshal, err)
+	}
+
+	metadata := map[string]string{MetadataContentType: ContentTypeJSON}
+	return ph.SendMessageToPlugin(pluginID, messageType, string(data), metadata)
+}
+
+// DecodeMessage 将JSON类型消息的内容解析到 dest
+// 与 PluginHost.SendMessageJSON 配套使用，消息未标注为JSON内容时返回错误
+func DecodeMessage(msg *proto.MessageRequest, dest interface{}) error {
+	if msg == nil {
+		return fmt.Errorf("消息为空")
+	}
+	if contentType := msg.Metadata[MetadataContentType]; contentType != ContentTypeJSON {
+		return fmt.Errorf("消息 %s 不是JSON内容: %q", msg.MessageId, contentType)
+	}
+	if err := json.Unmarshal([]byte(msg.Content), dest); err != nil {
+		return fmt.Errorf("解析消息 %s 失败: %v", msg.MessageId, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Package wwplugin 调用指标
+// 按调用方向统计调用次数、失败次数和耗时，覆盖主机→插件、插件→主机、插件→插件三个方向
+package wwplugin
+
+import (
+	"sort" // 排序，用于稳定输出指标
+	"sync" // 同步原语，保护指标表
+	"time" // 时间处理，用于统计耗时
+)
+
+// CallDirection 调用方向
+type CallDirection string
+
+// 调用方向常量定义
+const (
+	DirectionHostToPlugin   CallDirection = "host_to_plugin"   // 主机调用插件函数
+	DirectionPluginToHost   CallDirection = "plugin_to_host"   // 插件调用主机函数（包括流式主机函数）
+	DirectionPluginToPlugin CallDirection = "plugin_to_plugin" // 插件经主机转发调用其他插件
+)
+
+// CallMetricKey 调用指标标签
+type CallMetricKey struct {
+	Direction CallDirection `json:"direction"` // 调用方向
+	Source    string        `json:"source"`    // 调用方插件ID - 主机发起的调用为空
+	Target    string        `json:"target"`    // 被调用插件ID - 调用主机函数时为空
+	Function  string        `json:"function"`  // 函数名
+}
+
+// CallMetric 一组标签下的调用指标
+type CallMetric struct {
+	CallMetricKey
+	Calls        uint64        `json:"calls"`         // 调用次数
+	Errors       uint64        `json:"errors"`        // 失败次数 - 包括调用错误和失败响应
+	TotalLatency time.Duration `json:"total_latency"` // 累计耗时
+	MaxLatency   time.Duration `json:"max_latency"`   // 最长耗时
+}
+
+// AvgLatency 获取平均耗时，没有调用时返回0
+func (m CallMetric) AvgLatency() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.TotalLatency / time.Duration(m.Calls)
+}
+
+// callMetrics 调用指标表
+type callMetrics struct {
+	metrics map[CallMetricKey]*CallMetric // 按标签索引的指标
+	mutex   sync.Mutex                    // 指标表互斥锁
+}
+
+// record 记录一次调用
+func (cm *callMetrics) record(key CallMetricKey, elapsed time.Duration, failed bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.metrics == nil {
+		cm.metrics = make(map[CallMetricKey]*CallMetric)
+	}
+	metric, exists := cm.metrics[key]
+	if !exists {
+		metric = &CallMetric{CallMetricKey: key}
+		cm.metrics[key] = metric
+	}
+
+	metric.Calls++
+	if failed {
+		metric.Errors++
+	}
+	metric.TotalLatency += elapsed
+	if elapsed > metric.MaxLatency {
+		metric.MaxLatency = elapsed
+	}
+}
+
+// snapshot 获取指标副本（按方向、调用方、被调用方、函数名排序）
+func (cm *callMetrics) snapshot() []CallMetric {
+	cm.mutex.Lock()
+	result := make([]CallMetric, 0, len(cm.metrics))
+	for _, metric := range cm.metrics {
+		result = append(result, *metric)
+	}
+	cm.mutex.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].CallMetricKey, result[j].CallMetricKey
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Function < b.Function
+	})
+	return result
+}
+
+// CallMetrics 获取调用指标
+// 包括主机调用插件、插件调用主机函数、插件间调用三个方向
+func (ph *PluginHost) CallMetrics() []CallMetric {
+	return ph.metrics.snapshot()
+}
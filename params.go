@@ -0,0 +1,46 @@
+// Package wwplugin 参数辅助函数
+// 统一构造调用参数，避免手工拼接JSON等易错写法
+package wwplugin
+
+import (
+	"encoding/json" // JSON处理，用于序列化参数值
+	"fmt"           // 格式化输出，用于错误信息
+	"time"          // 时间处理，用于时间和时长参数
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// NewJSONParameter 创建JSON类型参数
+// 使用 encoding/json 序列化 v，保证生成合法的JSON；解析方可使用 (*proto.Parameter).DecodeJSON
+func NewJSONParameter(name string, v interface{}) (*proto.Parameter, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshal, err)
+	}
+
+	return &proto.Parameter{
+		Name:  name,
+		Type:  proto.ParameterType_JSON,
+		Value: string(data),
+	}, nil
+}
+
+// NewTimeParameter 创建TIMESTAMP类型参数
+// 值为RFC3339格式（保留纳秒精度），解析方可使用 (*proto.Parameter).AsTime
+func NewTimeParameter(name string, t time.Time) *proto.Parameter {
+	return &proto.Parameter{
+		Name:  name,
+		Type:  proto.ParameterType_TIMESTAMP,
+		Value: t.Format(time.RFC3339Nano),
+	}
+}
+
+// NewDurationParameter 创建DURATION类型参数
+// 值为 time.Duration 文本格式（如 "1m30s"），解析方可使用 (*proto.Parameter).AsDuration
+func NewDurationParameter(name string, d time.Duration) *proto.Parameter {
+	return &proto.Parameter{
+		Name:  name,
+		Type:  proto.ParameterType_DURATION,
+		Value: d.String(),
+	}
+}
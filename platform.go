@@ -0,0 +1,23 @@
+// Package wwplugin 平台相关的默认值
+// 根据 runtime.GOOS 生成可执行文件名、路径等默认配置，保证各平台开箱即用
+package wwplugin
+
+import (
+	"path/filepath" // 路径处理，用于拼接平台相关的路径
+	"runtime"       // 运行时信息，用于判断当前操作系统
+	"strings"       // 字符串处理，用于比较文件扩展名
+)
+
+// ExecutableName 返回当前平台下的可执行文件名
+// Windows 下为没有 .exe 后缀的名称追加后缀，其他平台原样返回
+func ExecutableName(name string) string {
+	if runtime.GOOS == "windows" && !strings.EqualFold(filepath.Ext(name), ".exe") {
+		return name + ".exe"
+	}
+	return name
+}
+
+// defaultLogDir 默认日志目录 - 工作目录下的 logs，使用平台路径分隔符
+func defaultLogDir() string {
+	return filepath.Join(".", "logs")
+}
@@ -5,18 +5,25 @@ package wwplugin
 import (
 	"context"       // 上下文控制，用于取消和超时管理
 	"encoding/json" // JSON编解码，用于插件信息序列化
+	"errors"        // 错误处理，用于判断错误类型
 	"fmt"           // 格式化输出，用于错误信息和日志
-	"log"           // 日志记录，用于运行时信息输出
+	"io"            // IO接口，用于判断流结束
+	"math/rand"     // 随机数，用于重连抖动
 	"net"           // 网络操作，用于创建gRPC服务器
 	"os"            // 操作系统接口，环境变量和信号处理
 	"os/signal"     // 系统信号处理，用于优雅关闭
+	"runtime/debug" // 运行时调试，用于获取panic堆栈
 	"strconv"       // 字符串转换，用于数据类型转换
+	"sync"          // 同步原语，保护调用统计
+	"sync/atomic"   // 原子操作，用于关闭原因代码
 	"syscall"       // 系统调用，用于信号处理
 	"time"          // 时间处理，心跳和超时管理
 
-	"github.com/wwwlkj/wwhyplugin/proto"          // gRPC协议定义
-	"google.golang.org/grpc"                      // gRPC框架
-	"google.golang.org/grpc/credentials/insecure" // gRPC安全凭据（不加密）
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+	"google.golang.org/grpc"             // gRPC框架
+	"google.golang.org/grpc/codes"       // gRPC状态码，用于区分错误类型
+	"google.golang.org/grpc/reflection"  // gRPC服务反射，用于调试
+	"google.golang.org/grpc/status"      // gRPC状态，用于解析错误
 )
 
 // Plugin 插件实例结构体
@@ -27,21 +34,54 @@ type Plugin struct {
 	ID        string                    // 插件唯一标识 - 由主机分配或自动生成
 	Port      int32                     // 插件服务端口 - 主机用此端口连接插件
 	functions map[string]PluginFunction // 插件函数映射 - 插件提供的可调用函数
+	timeouts  map[string]time.Duration  // 函数级调用超时 - 注册时随插件信息告知主机
+
+	defaultHandler PluginFunction // 默认处理器 - 没有同名函数时调用，为nil时返回 FUNCTION_NOT_FOUND
 
 	// === gRPC 相关 === //
 	GrpcServer *grpc.Server            // gRPC服务器 - 提供插件服务接口
 	HostConn   *grpc.ClientConn        // 主机连接 - 连接到主机的gRPC客户端
 	HostClient proto.HostServiceClient // 主机客户端 - 用于调用主机服务
 
+	hosts      map[string]*hostConnection // 额外主机连接 - 通过 ConnectHost 按名称连接的其他主机
+	hostsMutex sync.RWMutex               // 额外主机连接互斥锁
+
+	callToken atomic.Value // 插件间调用凭证 - 每次注册成功后保存主机签发的凭证（string），插件间调用时携带
+
 	// === 控制组件 === //
 	ctx               context.Context    // 上下文控制 - 用于统一取消操作
 	cancel            context.CancelFunc // 取消函数 - 用于停止所有子操作
 	isShuttingDown    bool               // 关闭标志 - 标记插件是否正在关闭
+	started           int32              // 启动标志 - 原子操作，防止重复启动
 	reconnectInterval time.Duration      // 重连间隔 - 连接断开后的重连等待时间
 	maxReconnectTries int                // 最大重连次数 - 0表示无限重连
+	stopErr           error              // 终止错误 - 插件因错误停止时记录，通过 StartAsync 的通道送达
+	stopErrMutex      sync.Mutex         // 终止错误互斥锁
+	heartbeatReset    chan time.Duration // 心跳间隔调整 - ConfigureHeartbeat 写入，心跳循环据此重置定时器
+	stopped           chan struct{}      // 停止完成通知 - Stop 完成清理后关闭，Start 等待到此才返回
+	stopOnce          sync.Once          // 保证停止流程只执行一次
 
 	// === 消息处理 === //
-	messageHandler MessageHandler // 消息处理器 - 处理主机推送的消息
+	messageHandlers []MessageHandler // 消息处理器列表 - 按添加顺序依次处理主机推送的消息
+	messageMutex    sync.RWMutex     // 消息处理器列表读写锁
+
+	messageQueue chan *queuedMessage // 消息队列 - 启用工作池时由 ReceiveMessages 写入、工作协程取出处理，未启用时为nil
+
+	shutdownReason int32           // 主机关闭请求的原因代码 - proto.ShutdownReason，原子访问
+	shutdownFunc   ShutdownHandler // 关闭处理器 - 收到关闭请求时调用，可延迟或拒绝关闭
+	configHandler  ConfigHandler   // 配置处理器 - 接收主机推送的配置
+	healthFunc     HealthFunc      // 健康状态回调 - 每次心跳时调用，为nil时上报 running
+	readyFunc      ReadyHandler    // 就绪回调 - 注册到主机后、开始心跳前调用一次
+
+	disconnectFunc ConnectionHandler // 主机断开回调 - 连接监控器判定主机连接中断时调用
+	reconnectFunc  ConnectionHandler // 主机恢复回调 - 连接中断后重新连上主机时调用
+
+	// === 调用统计 === //
+	stats callStats // 函数调用统计 - 通过 GetPluginStatus 的指标上报
+
+	// === 日志 === //
+	logger Logger    // 日志接口 - 来自配置或默认的标准库实现
+	logs   logBuffer // 日志缓冲区 - Plugin.Log 写入，定期批量上报主机
 }
 
 // NewPlugin 创建新的插件实例
@@ -50,47 +90,103 @@ func NewPlugin(config *PluginConfig) *Plugin {
 		config = DefaultPluginConfig("UnnamedPlugin", "1.0.0", "A plugin created with WWPlugin")
 	}
 
+	logger := config.Logger
+	if logger == nil {
+		logger = NewStdLogger(INFO)
+	}
+	logger = withFields(logger, "component", "plugin", "plugin_name", config.Name)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	plugin := &Plugin{
 		config:            config,
+		logger:            logger,
+		logs:              logBuffer{flush: make(chan struct{}, 1)},
 		functions:         make(map[string]PluginFunction),
+		timeouts:          make(map[string]time.Duration),
+		hosts:             make(map[string]*hostConnection),
 		ctx:               ctx,
 		cancel:            cancel,
 		reconnectInterval: config.ReconnectInterval,
 		maxReconnectTries: config.MaxReconnectTries,
+		heartbeatReset:    make(chan time.Duration, 1),
+		stopped:           make(chan struct{}),
 	}
 
 	// 生成插件ID
 	if plugin.ID == "" {
-		plugin.ID = fmt.Sprintf("%s-%d", config.Name, time.Now().Unix())
+		plugin.ID = plugin.generateID()
 	}
 
 	return plugin
 }
 
-// Start 启动插件
+// Start 启动插件并阻塞，直到收到退出信号或插件被关闭
+// 返回插件的终止错误：主动关闭或收到退出信号时为nil，因主机断开等错误关闭时为对应错误（如 ErrHostDisconnected）
 func (p *Plugin) Start() error {
+	if _, err := p.StartAsync(); err != nil {
+		return err
+	}
+
+	// 等待信号
+	p.waitForSignal()
+
+	return p.terminalError()
+}
+
+// StartAsync 启动插件后立即返回，适用于将插件嵌入到更大的程序中
+// 插件停止时通道送达终止错误（主动关闭时为nil）后关闭；不处理退出信号，由调用方负责调用 Stop
+// 每个插件只能启动一次（启动失败后同样不能再次启动），重复调用返回 ErrAlreadyStarted
+func (p *Plugin) StartAsync() (<-chan error, error) {
+	if !atomic.CompareAndSwapInt32(&p.started, 0, 1) {
+		return nil, ErrAlreadyStarted
+	}
+
 	// 从环境变量获取主机地址
 	if hostAddr := os.Getenv("HOST_GRPC_ADDRESS"); hostAddr != "" {
 		p.config.HostAddress = hostAddr
 	}
 
-	log.Printf("启动插件: %s (ID: %s)", p.config.Name, p.ID)
+	// 未配置发现文件时使用主机传递的路径
+	if p.config.HostDiscoveryFile == "" {
+		p.config.HostDiscoveryFile = os.Getenv(envHostDiscoveryFile)
+	}
+
+	// 由主机启动时使用主机分配的ID，保证重启/升级后身份不变
+	if pluginID := os.Getenv("PLUGIN_ID"); pluginID != "" {
+		p.ID = pluginID
+	}
+
+	p.logger.Info("启动插件", "plugin_name", p.config.Name, "plugin_id", p.ID)
+
+	// 消息工作池需在gRPC服务开始接收消息前就绪
+	p.startMessageWorkers()
 
 	// 启动gRPC服务器
 	if err := p.startGrpcServer(); err != nil {
-		return fmt.Errorf("启动gRPC服务器失败: %v", err)
+		p.Stop()
+		return nil, fmt.Errorf("启动gRPC服务器失败: %v", err)
 	}
 
 	// 连接到主机
 	if err := p.connectToHost(); err != nil {
-		return fmt.Errorf("连接主机失败: %v", err)
+		p.Stop()
+		return nil, fmt.Errorf("连接主机失败: %v", err)
 	}
 
-	// 注册到主机
-	if err := p.registerToHost(); err != nil {
-		return fmt.Errorf("注册到主机失败: %v", err)
+	// 注册到主机（主机尚未就绪时按配置重试）
+	if err := p.registerWithRetry(); err != nil {
+		p.Stop()
+		return nil, fmt.Errorf("注册到主机失败: %v", err)
+	}
+
+	// 执行就绪回调
+	if p.readyFunc != nil {
+		if err := p.readyFunc(); err != nil {
+			p.logger.Error("❌ 插件就绪回调失败", "error", err)
+			p.Stop()
+			return nil, fmt.Errorf("插件初始化失败: %v", err)
+		}
 	}
 
 	// 启动心跳
@@ -99,47 +195,186 @@ func (p *Plugin) Start() error {
 	// 启动连接监控
 	go p.startConnectionMonitor()
 
-	// 等待信号
-	p.waitForSignal()
+	// 启动日志上报
+	go p.startLogFlusher()
 
-	return nil
+	// 插件停止并完成清理后送达终止错误
+	done := make(chan error, 1)
+	go func() {
+		<-p.stopped
+		done <- p.terminalError()
+		close(done)
+	}()
+
+	return done, nil
 }
 
 // Stop 停止插件
+// 可重复调用，只有第一次调用执行停止流程，并发的调用等待其完成后返回
 func (p *Plugin) Stop() {
-	log.Printf("停止插件: %s", p.config.Name)
+	p.stopOnce.Do(p.stop)
+}
+
+// stop 执行停止流程，完成后关闭停止完成通知
+func (p *Plugin) stop() {
+	p.logger.Info("停止插件", "plugin_name", p.config.Name, "plugin_id", p.ID)
 
 	p.isShuttingDown = true
 
 	// 取消上下文
 	p.cancel()
 
-	// 停止gRPC服务器
+	// 停止gRPC服务器（超时后强制关闭）
 	if p.GrpcServer != nil {
-		p.GrpcServer.GracefulStop()
+		stopGrpcServer(p.GrpcServer, p.config.GracefulStopTimeout, p.logger)
 	}
 
-	// 关闭主机连接
+	// 上报剩余日志后关闭主机连接
+	p.flushLogs()
 	if p.HostConn != nil {
 		p.HostConn.Close()
 	}
+	p.closeHosts()
 
-	log.Printf("插件已停止: %s", p.config.Name)
+	p.logger.Info("插件已停止", "plugin_name", p.config.Name, "plugin_id", p.ID)
+	close(p.stopped)
+}
+
+// stopWithError 因错误停止插件，记录第一个终止错误
+func (p *Plugin) stopWithError(err error) {
+	p.stopErrMutex.Lock()
+	if p.stopErr == nil {
+		p.stopErr = err
+	}
+	p.stopErrMutex.Unlock()
+
+	p.Stop()
+}
+
+// terminalError 获取插件的终止错误，主动关闭时为nil
+func (p *Plugin) terminalError() error {
+	p.stopErrMutex.Lock()
+	defer p.stopErrMutex.Unlock()
+	return p.stopErr
 }
 
 // RegisterFunction 注册插件函数
+// 同名函数已存在时会覆盖并输出警告；开启StrictFunctionRegistration时直接panic
 func (p *Plugin) RegisterFunction(name string, fn PluginFunction) {
+	if _, exists := p.functions[name]; exists {
+		if p.config.StrictFunctionRegistration {
+			panic(fmt.Sprintf("插件函数重复注册: %s", name))
+		}
+		p.logger.Warn("⚠️ 插件函数已存在，将被覆盖", "function", name)
+	}
 	p.functions[name] = fn
-	log.Printf("已注册插件函数: %s", name)
+	p.logger.Info("已注册插件函数", "function", name)
 }
 
-// SetMessageHandler 设置消息处理器
+// RegisterFunctionWithTimeout 注册插件函数并声明该函数的调用超时
+// 主机调用该函数时使用此超时代替默认超时，适用于明显慢于其他函数的操作
+func (p *Plugin) RegisterFunctionWithTimeout(name string, fn PluginFunction, timeout time.Duration) {
+	p.RegisterFunction(name, fn)
+	if timeout > 0 {
+		p.timeouts[name] = timeout
+	} else {
+		delete(p.timeouts, name)
+	}
+}
+
+// RegisterFunctionE 注册插件函数，同名函数已存在时返回错误而不覆盖
+func (p *Plugin) RegisterFunctionE(name string, fn PluginFunction) error {
+	if _, exists := p.functions[name]; exists {
+		return fmt.Errorf("插件函数 %s 已注册", name)
+	}
+	p.functions[name] = fn
+	p.logger.Info("已注册插件函数", "function", name)
+	return nil
+}
+
+// SetMessageHandler 设置消息处理器，替换之前设置或添加的所有处理器
 func (p *Plugin) SetMessageHandler(handler MessageHandler) {
-	p.messageHandler = handler
+	p.messageMutex.Lock()
+	defer p.messageMutex.Unlock()
+	p.messageHandlers = nil
+	if handler != nil {
+		p.messageHandlers = []MessageHandler{handler}
+	}
+}
+
+// AddMessageHandler 添加消息处理器
+// 每条消息按添加顺序交给所有处理器，插件的不同模块可以各自订阅消息而无需共用一个处理器
+func (p *Plugin) AddMessageHandler(handler MessageHandler) {
+	if handler == nil {
+		return
+	}
+	p.messageMutex.Lock()
+	defer p.messageMutex.Unlock()
+	p.messageHandlers = append(p.messageHandlers, handler)
+}
+
+// SetShutdownHandler 设置关闭处理器
+// 收到主机关闭请求时调用：返回的宽限时间（秒）决定插件多久后停止，主机按此等待（不超过主机配置的上限）；
+// 返回错误表示当前不能关闭，插件继续运行。主机自身关闭时拒绝无效，宽限期后插件仍会被强制终止
+func (p *Plugin) SetShutdownHandler(handler ShutdownHandler) {
+	p.shutdownFunc = handler
+}
+
+// SetDefaultHandler 设置默认函数处理器
+// 调用的函数未注册时交给默认处理器，处理器可通过 FunctionNameFromContext 获取请求的函数名称；
+// 适用于无法预先列举函数的插件（如脚本引擎代理）
+func (p *Plugin) SetDefaultHandler(fn PluginFunction) {
+	p.defaultHandler = fn
+}
+
+// SetConfigHandler 设置配置处理器
+// 主机每次连接插件后推送 PluginInfo.PluginConfigData，运行中调用 PluginHost.SetPluginConfig 时也会推送
+func (p *Plugin) SetConfigHandler(handler ConfigHandler) {
+	p.configHandler = handler
+}
+
+// OnReady 设置就绪回调
+// 插件首次成功注册到主机后、开始发送心跳前调用，适合执行依赖主机连接的初始化（如预热缓存）；
+// 回调返回错误时插件停止，Start/StartAsync 返回该错误
+func (p *Plugin) OnReady(fn ReadyHandler) {
+	p.readyFunc = fn
+}
+
+// OnHostDisconnect 设置主机断开回调
+// 连接监控器判定主机连接中断时调用一次，可用于暂停接收任务或缓存待上报数据；回调在监控协程中执行，应尽快返回
+func (p *Plugin) OnHostDisconnect(fn ConnectionHandler) {
+	p.disconnectFunc = fn
+}
+
+// OnHostReconnect 设置主机恢复回调
+// 主机连接中断后重新连上时调用一次；回调在监控协程中执行，应尽快返回
+func (p *Plugin) OnHostReconnect(fn ConnectionHandler) {
+	p.reconnectFunc = fn
+}
+
+// SetHealth 设置健康状态回调
+// 每次心跳时调用，返回的状态和详情随心跳上报主机；插件存活但依赖异常时可返回 "degraded"
+func (p *Plugin) SetHealth(fn HealthFunc) {
+	p.healthFunc = fn
 }
 
 // CallHostFunction 调用主机函数
 func (p *Plugin) CallHostFunction(functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
+	return p.callHostFunction(p.HostClient, DefaultHostName, functionName, params)
+}
+
+// CallHostFunctionOn 调用指定主机上的函数
+// hostName 为空或 DefaultHostName 时调用插件注册所在的主机，其余名称需先通过 ConnectHost 连接
+func (p *Plugin) CallHostFunctionOn(hostName string, functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
+	client, err := p.hostClient(hostName)
+	if err != nil {
+		return nil, err
+	}
+	return p.callHostFunction(client, hostName, functionName, params)
+}
+
+// callHostFunction 通过指定的主机客户端调用主机函数
+func (p *Plugin) callHostFunction(client proto.HostServiceClient, hostName string, functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
 	req := &proto.CallRequest{
 		FunctionName: functionName,
 		Parameters:   params,
@@ -154,26 +389,91 @@ func (p *Plugin) CallHostFunction(functionName string, params []*proto.Parameter
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	log.Printf("调用主机函数: %s", functionName)
+	p.logger.Info("调用主机函数", "host", hostName, "function", functionName, "request_id", req.RequestId)
 
-	resp, err := p.HostClient.CallHostFunction(ctx, req)
+	resp, err := client.CallHostFunction(ctx, req)
 	if err != nil {
-		log.Printf("调用主机函数失败: %v", err)
+		p.logger.Error("调用主机函数失败", "host", hostName, "function", functionName, "request_id", req.RequestId, "error", err)
 		return nil, err
 	}
 
 	if resp.Success {
-		log.Printf("主机函数调用成功: %s", functionName)
+		p.logger.Info("主机函数调用成功", "host", hostName, "function", functionName, "request_id", req.RequestId)
 	} else {
-		log.Printf("主机函数调用失败: %s", resp.Message)
+		p.logger.Warn("主机函数调用失败", "host", hostName, "function", functionName, "request_id", req.RequestId, "message", resp.Message)
 	}
 
 	return resp, nil
 }
 
+// CallHostFunctionStream 调用流式主机函数
+// 结果通过返回的通道逐条送达，主机函数结束后通道关闭；调用出错时最后一条的 Err 非nil
+// 取消 ctx 可提前结束调用，未取消时调用方应持续读取通道直到关闭
+func (p *Plugin) CallHostFunctionStream(ctx context.Context, functionName string, params []*proto.Parameter) (<-chan StreamItem, error) {
+	req := &proto.CallRequest{
+		FunctionName: functionName,
+		Parameters:   params,
+		RequestId:    fmt.Sprintf("plugin-%s-%d", p.ID, time.Now().UnixNano()),
+		Metadata: map[string]string{
+			"source":    "plugin",
+			"plugin_id": p.ID,
+			"timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+		},
+	}
+
+	p.logger.Info("调用流式主机函数", "function", functionName, "request_id", req.RequestId)
+
+	stream, err := p.HostClient.CallHostFunctionStream(ctx, req)
+	if err != nil {
+		p.logger.Error("调用流式主机函数失败", "function", functionName, "request_id", req.RequestId, "error", err)
+		return nil, err
+	}
+
+	items := make(chan StreamItem)
+	go func() {
+		defer close(items)
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+
+			var item StreamItem
+			switch {
+			case err != nil:
+				item.Err = err
+			case !resp.Success:
+				item.Err = fmt.Errorf("主机函数 %s 调用失败 [%s]: %s", functionName, resp.ErrorCode, resp.Message)
+			default:
+				item.Value = resp.Result
+			}
+
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				return
+			}
+			if item.Err != nil {
+				p.logger.Warn("流式主机函数调用结束", "function", functionName, "request_id", req.RequestId, "error", item.Err)
+				return
+			}
+		}
+	}()
+
+	return items, nil
+}
+
 // CallOtherPlugin 调用其他插件函数
-// 这是插件间调用的核心方法，通过主机作为中介来调用其他插件的函数
+// 通过主机作为中介来调用其他插件的函数；不携带调用链路，主机无法检测经过该调用的循环调用
+//
+// Deprecated: 使用 CallOtherPluginCtx 并传入插件函数收到的 ctx，调用链路和截止时间才能随调用传递
 func (p *Plugin) CallOtherPlugin(targetPluginID string, functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
+	return p.CallOtherPluginCtx(context.Background(), targetPluginID, functionName, params)
+}
+
+// CallOtherPluginCtx 在给定上下文中调用其他插件函数
+// 传入插件函数收到的 ctx 时，调用链路和截止时间随调用传递，主机据此拒绝循环调用（CALL_LOOP_DETECTED）
+func (p *Plugin) CallOtherPluginCtx(ctx context.Context, targetPluginID string, functionName string, params []*proto.Parameter) (*proto.CallResponse, error) {
 	req := &proto.CallRequest{
 		FunctionName: functionName,
 		Parameters:   params,
@@ -186,28 +486,97 @@ func (p *Plugin) CallOtherPlugin(targetPluginID string, functionName string, par
 			"timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
 		},
 	}
+	if path := RequestMetadataFromContext(ctx)[metadataCallPath]; path != "" {
+		req.Metadata[metadataCallPath] = path
+	}
+	if token, _ := p.callToken.Load().(string); token != "" {
+		req.Metadata[metadataCallToken] = token
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	log.Printf("调用插件函数: %s -> %s.%s", p.ID, targetPluginID, functionName)
+	p.logger.Info("调用插件函数", "target_plugin", targetPluginID, "function", functionName, "request_id", req.RequestId)
 
 	// 通过主机的CallHostFunction接口转发请求
 	resp, err := p.HostClient.CallHostFunction(ctx, req)
 	if err != nil {
-		log.Printf("调用插件函数失败: %v", err)
+		p.logger.Error("调用插件函数失败", "target_plugin", targetPluginID, "function", functionName, "request_id", req.RequestId, "error", err)
 		return nil, err
 	}
 
 	if resp.Success {
-		log.Printf("插件函数调用成功: %s -> %s.%s", p.ID, targetPluginID, functionName)
+		p.logger.Info("插件函数调用成功", "target_plugin", targetPluginID, "function", functionName, "request_id", req.RequestId)
 	} else {
-		log.Printf("插件函数调用失败: %s", resp.Message)
+		p.logger.Warn("插件函数调用失败", "target_plugin", targetPluginID, "function", functionName, "request_id", req.RequestId, "message", resp.Message)
 	}
 
 	return resp, nil
 }
 
+// UpdateCapabilities 运行时更新插件能力列表并推送到主机
+// 主机的能力路由会立即反映新的能力列表；尚未连接主机时仅更新本地配置
+func (p *Plugin) UpdateCapabilities(caps []string) error {
+	p.config.Capabilities = caps
+
+	if p.HostClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := p.HostClient.UpdateCapabilities(ctx, &proto.CapabilitiesRequest{
+		PluginId:     p.ID,
+		Capabilities: caps,
+	})
+	if err != nil {
+		return fmt.Errorf("推送能力列表失败: %v", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("主机拒绝更新能力列表: %s", resp.Message)
+	}
+
+	p.logger.Info("插件能力已更新", "capabilities", caps)
+	return nil
+}
+
+// ListHostFunctions 查询主机提供的函数列表
+// 插件可在启动时检查依赖的主机函数是否可用，避免调用时才得到 FUNCTION_NOT_FOUND
+func (p *Plugin) ListHostFunctions() ([]string, error) {
+	if p.HostClient == nil {
+		return nil, fmt.Errorf("未连接到主机")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := p.HostClient.ListHostFunctions(ctx, &proto.ListFunctionsRequest{
+		PluginId: p.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询主机函数列表失败: %v", err)
+	}
+
+	return resp.FunctionNames, nil
+}
+
+// PingHost 测量插件到主机的往返延迟
+func (p *Plugin) PingHost() (time.Duration, error) {
+	if p.HostClient == nil {
+		return 0, fmt.Errorf("未连接到主机")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := p.HostClient.Echo(ctx, &proto.EchoRequest{Payload: p.ID}); err != nil {
+		return 0, fmt.Errorf("探测主机失败: %v", err)
+	}
+	return time.Since(start), nil
+}
+
 // GetConfig 获取插件配置
 func (p *Plugin) GetConfig() *PluginConfig {
 	return p.config
@@ -218,7 +587,7 @@ func (p *Plugin) GetConfig() *PluginConfig {
 func (p *Plugin) GetPluginInfo() *PluginBasicInfo {
 	// 如果还没有ID，先生成一个
 	if p.ID == "" {
-		p.ID = fmt.Sprintf("%s-%d", p.config.Name, time.Now().Unix())
+		p.ID = p.generateID()
 	}
 
 	return &PluginBasicInfo{
@@ -229,6 +598,8 @@ func (p *Plugin) GetPluginInfo() *PluginBasicInfo {
 		Logo:         p.config.Logo,
 		Capabilities: p.config.Capabilities,
 		Functions:    p.getFunctionList(),
+
+		FunctionTimeouts: p.functionTimeoutsMs(),
 	}
 }
 
@@ -248,16 +619,32 @@ func (p *Plugin) StartWithInfo() error {
 	return nil
 }
 
+// infoFlag 主机查询插件信息时传给插件的命令行参数
+const infoFlag = "--info"
+
+// RunPluginMain 插件程序的统一入口，封装 --info 查询和正常启动
+// args 通常传入 os.Args：带 --info 参数时输出插件信息后返回，否则启动插件并阻塞到插件退出
+func RunPluginMain(plugin *Plugin, args []string) error {
+	if len(args) > 1 && args[1] == infoFlag {
+		return plugin.StartWithInfo()
+	}
+	return plugin.Start()
+}
+
 // PluginService接口实现
 
 // CallPluginFunction 主机调用插件函数
 func (p *Plugin) CallPluginFunction(ctx context.Context, req *proto.CallRequest) (*proto.CallResponse, error) {
-	log.Printf("收到函数调用请求: %s (请求ID: %s)", req.FunctionName, req.RequestId)
+	p.logger.Info("收到函数调用请求", "function", req.FunctionName, "request_id", req.RequestId)
 
 	// 查找函数
 	fn, exists := p.functions[req.FunctionName]
+	if !exists && p.defaultHandler != nil {
+		p.logger.Debug("使用默认处理器", "function", req.FunctionName, "request_id", req.RequestId)
+		fn, exists = p.defaultHandler, true
+	}
 	if !exists {
-		log.Printf("未找到函数: %s", req.FunctionName)
+		p.logger.Warn("未找到函数", "function", req.FunctionName, "request_id", req.RequestId)
 		return &proto.CallResponse{
 			Success:   false,
 			Message:   fmt.Sprintf("未找到函数: %s", req.FunctionName),
@@ -266,19 +653,33 @@ func (p *Plugin) CallPluginFunction(ctx context.Context, req *proto.CallRequest)
 		}, nil
 	}
 
-	// 调用函数
-	result, err := fn(ctx, req.Parameters)
+	// 调用函数，请求ID、元数据、函数名称和收到调用的时间通过上下文传递给函数
+	received := time.Now()
+	callCtx := withCallReceived(withRequest(ctx, req.RequestId, req.Metadata), received)
+	callCtx = withFunctionName(callCtx, req.FunctionName)
+	result, err := p.invokeFunction(callCtx, req, fn)
+	elapsed := time.Since(received)
+	p.stats.record(elapsed)
+	p.logger.Debug("函数执行耗时",
+		"function", req.FunctionName,
+		"request_id", req.RequestId,
+		"elapsed", elapsed,
+		"delivery_latency", DeliveryLatencyFromContext(callCtx))
 	if err != nil {
-		log.Printf("函数调用失败: %v", err)
+		p.logger.Error("函数调用失败", "function", req.FunctionName, "request_id", req.RequestId, "error", err)
+		errorCode := "FUNCTION_ERROR"
+		if errors.Is(err, ErrFunctionPanic) {
+			errorCode = ErrorCodeFunctionPanic
+		}
 		return &proto.CallResponse{
 			Success:   false,
 			Message:   err.Error(),
-			ErrorCode: "FUNCTION_ERROR",
+			ErrorCode: errorCode,
 			RequestId: req.RequestId,
 		}, nil
 	}
 
-	log.Printf("函数调用成功: %s", req.FunctionName)
+	p.logger.Info("函数调用成功", "function", req.FunctionName, "request_id", req.RequestId)
 	return &proto.CallResponse{
 		Success:   true,
 		Message:   "调用成功",
@@ -287,33 +688,169 @@ func (p *Plugin) CallPluginFunction(ctx context.Context, req *proto.CallRequest)
 	}, nil
 }
 
+// CallDuration 获取当前调用自插件收到以来的耗时
+// 在插件函数中调用，可用于统计处理耗时；不在调用上下文中时返回0
+func (p *Plugin) CallDuration(ctx context.Context) time.Duration {
+	received, ok := ctx.Value(callReceivedKey).(time.Time)
+	if !ok {
+		return 0
+	}
+	return time.Since(received)
+}
+
+// invokeFunction 执行插件函数并恢复panic
+// 发生panic时返回 ErrFunctionPanic，并将堆栈上报给主机
+func (p *Plugin) invokeFunction(ctx context.Context, req *proto.CallRequest, fn PluginFunction) (result *proto.Parameter, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := string(debug.Stack())
+			p.logger.Error("🔥 函数执行发生panic", "function", req.FunctionName, "request_id", req.RequestId, "panic", r, "stack", stack)
+			go p.reportPanic(req.FunctionName, req.RequestId, r, stack)
+			result, err = nil, fmt.Errorf("%w: %v", ErrFunctionPanic, r)
+		}
+	}()
+	return fn(ctx, req.Parameters)
+}
+
+// reportPanic 通过ReportLog将panic信息上报给主机
+func (p *Plugin) reportPanic(functionName, requestID string, r interface{}, stack string) {
+	if p.HostClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := p.HostClient.ReportLog(ctx, &proto.LogRequest{
+		PluginId:  p.ID,
+		Level:     proto.LogLevel_ERROR,
+		Message:   fmt.Sprintf("函数 %s 发生panic (请求ID: %s): %v\n%s", functionName, requestID, r, stack),
+		Timestamp: time.Now().Unix(),
+		Category:  logCategoryPanic,
+	})
+	if err != nil {
+		p.logger.Warn("上报panic失败", "function", functionName, "request_id", requestID, "error", err)
+	}
+}
+
 // ReceiveMessages 接收主机推送的消息
 func (p *Plugin) ReceiveMessages(stream proto.PluginService_ReceiveMessagesServer) error {
-	log.Println("开始接收消息流...")
+	p.logger.Debug("开始接收消息流...")
 
-	var messageCount int32 = 0
+	var received int32 = 0
+	batch := &messageBatch{signal: make(chan struct{}, 1)}
 
+receive:
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
 			break
 		}
+		received++
 
-		messageCount++
-		log.Printf("收到消息: %s - %s (ID: %s)", msg.MessageType, msg.Content, msg.MessageId)
+		p.logger.Info("收到消息", "message_type", msg.MessageType, "message_id", msg.MessageId)
 
-		// 处理消息
-		p.handleMessage(msg)
+		// 未启用工作池时直接处理
+		if p.messageQueue == nil {
+			p.handleMessage(msg)
+			atomic.AddInt32(&batch.handled, 1)
+			continue
+		}
+
+		// 交给工作池处理，队列满时在此等待，暂停接收后续消息
+		atomic.AddInt32(&batch.pending, 1)
+		select {
+		case p.messageQueue <- &queuedMessage{msg: msg, batch: batch}:
+		case <-stream.Context().Done():
+			atomic.AddInt32(&batch.pending, -1)
+			break receive
+		case <-p.ctx.Done():
+			atomic.AddInt32(&batch.pending, -1)
+			break receive
+		}
 	}
 
-	// 发送响应
+	// 等待本消息流已入队的消息处理完成，插件停止或主机断开时不再等待
+	for atomic.LoadInt32(&batch.pending) > 0 {
+		select {
+		case <-batch.signal:
+		case <-stream.Context().Done():
+		case <-p.ctx.Done():
+		}
+		if stream.Context().Err() != nil || p.ctx.Err() != nil {
+			break
+		}
+	}
+
+	// 发送响应，ProcessedCount 为已处理完成的消息数
+	handled := atomic.LoadInt32(&batch.handled)
+	if dropped := received - handled; dropped > 0 {
+		p.logger.Warn("部分消息未处理", "received", received, "handled", handled, "dropped", dropped)
+		return stream.SendAndClose(&proto.MessageResponse{
+			Success:        false,
+			Message:        fmt.Sprintf("插件已停止，%d 条消息未处理", dropped),
+			ProcessedCount: handled,
+		})
+	}
 	return stream.SendAndClose(&proto.MessageResponse{
 		Success:        true,
 		Message:        "消息处理完成",
-		ProcessedCount: messageCount,
+		ProcessedCount: handled,
 	})
 }
 
+// queuedMessage 工作池队列中的消息
+type queuedMessage struct {
+	msg   *proto.MessageRequest // 主机推送的消息
+	batch *messageBatch         // 消息所属的消息流
+}
+
+// messageBatch 一次消息流中交给工作池的消息 - 用于等待处理完成并统计处理数量
+type messageBatch struct {
+	pending int32         // 已入队尚未处理完成的消息数，原子访问
+	handled int32         // 已处理完成的消息数，原子访问
+	signal  chan struct{} // 消息处理完成通知 - 容量为1，通知可合并
+}
+
+// done 标记一条消息处理完成
+func (b *messageBatch) done() {
+	atomic.AddInt32(&b.handled, 1)
+	atomic.AddInt32(&b.pending, -1)
+	select {
+	case b.signal <- struct{}{}:
+	default:
+	}
+}
+
+// startMessageWorkers 按配置启动消息处理工作池，MessageWorkers 不大于0时不启用
+// 插件停止时工作协程退出，队列中尚未处理的消息被丢弃
+func (p *Plugin) startMessageWorkers() {
+	workers := p.config.MessageWorkers
+	if workers <= 0 {
+		return
+	}
+	queueSize := p.config.MessageQueueSize
+	if queueSize <= 0 {
+		queueSize = workers
+	}
+
+	p.messageQueue = make(chan *queuedMessage, queueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-p.ctx.Done():
+					return
+				case item := <-p.messageQueue:
+					p.handleMessage(item.msg)
+					item.batch.done()
+				}
+			}
+		}()
+	}
+	p.logger.Debug("消息工作池已启动", "workers", workers, "queue_size", queueSize)
+}
+
 // GetPluginStatus 获取插件状态
 func (p *Plugin) GetPluginStatus(ctx context.Context, req *proto.StatusRequest) (*proto.StatusResponse, error) {
 	uptime := time.Since(time.Unix(0, 0)).String() // 简化的运行时间计算
@@ -336,6 +873,7 @@ func (p *Plugin) GetPluginStatus(ctx context.Context, req *proto.StatusRequest)
 			"plugin_id":      p.ID,
 			"port":           fmt.Sprintf("%d", p.Port),
 		}
+		p.stats.addMetrics(resp.Metrics)
 	}
 
 	return resp, nil
@@ -343,25 +881,127 @@ func (p *Plugin) GetPluginStatus(ctx context.Context, req *proto.StatusRequest)
 
 // Shutdown 插件关闭通知
 func (p *Plugin) Shutdown(ctx context.Context, req *proto.ShutdownRequest) (*proto.ShutdownResponse, error) {
-	log.Printf("收到关闭请求: %s", req.Reason)
+	p.logger.Info("收到关闭请求", "reason", req.Reason, "reason_code", req.ReasonCode)
+	atomic.StoreInt32(&p.shutdownReason, int32(req.ReasonCode))
+
+	// 由关闭处理器决定宽限时间或拒绝关闭，强制关闭时不询问
+	graceSeconds := 0
+	if p.shutdownFunc != nil && !req.Force {
+		seconds, err := p.shutdownFunc(req.Reason)
+		if err != nil {
+			p.logger.Warn("⚠️ 关闭处理器拒绝关闭", "reason", req.Reason, "error", err)
+			return &proto.ShutdownResponse{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		graceSeconds = seconds
+	}
 
 	// 标记正在关闭
 	p.isShuttingDown = true
 
 	// 延迟关闭，给当前请求时间完成
+	delay := time.Second
+	if graceSeconds > 0 {
+		delay = time.Duration(graceSeconds) * time.Second
+	}
 	go func() {
-		time.Sleep(1 * time.Second)
+		time.Sleep(delay)
 		p.Stop()
 	}()
 
 	return &proto.ShutdownResponse{
+		Success:      true,
+		Message:      "插件正在关闭",
+		GraceSeconds: int32(graceSeconds),
+	}, nil
+}
+
+// PushConfig 接收主机推送的配置
+func (p *Plugin) PushConfig(ctx context.Context, req *proto.ConfigRequest) (*proto.ConfigResponse, error) {
+	if p.configHandler == nil {
+		p.logger.Warn("⚠️ 收到主机配置，但未设置配置处理器", "keys", len(req.Config))
+		return &proto.ConfigResponse{
+			Success: false,
+			Message: "插件未设置配置处理器",
+		}, nil
+	}
+
+	p.logger.Info("收到主机配置", "keys", len(req.Config))
+	p.configHandler(req.Config)
+	return &proto.ConfigResponse{
 		Success: true,
-		Message: "插件正在关闭",
+		Message: "配置已应用",
+	}, nil
+}
+
+// Echo 原样返回请求内容，供主机测量往返延迟和检测存活
+func (p *Plugin) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.EchoResponse, error) {
+	return &proto.EchoResponse{Payload: req.Payload}, nil
+}
+
+// ConfigureHeartbeat 按主机要求调整心跳间隔
+// 主机怀疑插件不稳定时可临时缩短间隔加强监测，间隔为0时恢复插件配置的间隔
+func (p *Plugin) ConfigureHeartbeat(ctx context.Context, req *proto.HeartbeatConfigRequest) (*proto.HeartbeatConfigResponse, error) {
+	if req.IntervalSeconds < 0 {
+		return &proto.HeartbeatConfigResponse{
+			Success: false,
+			Message: fmt.Sprintf("心跳间隔无效: %d", req.IntervalSeconds),
+		}, nil
+	}
+
+	interval := p.config.HeartbeatInterval
+	if req.IntervalSeconds > 0 {
+		interval = time.Duration(req.IntervalSeconds) * time.Second
+	}
+
+	// 只保留最新的间隔：通道已满时丢弃心跳循环尚未取走的旧值后重试，并发调整或插件已停止时都不会阻塞
+	for sent := false; !sent; {
+		select {
+		case p.heartbeatReset <- interval:
+			sent = true
+		case <-p.ctx.Done():
+			return &proto.HeartbeatConfigResponse{
+				Success: false,
+				Message: "插件正在停止",
+			}, nil
+		default:
+			select {
+			case <-p.heartbeatReset:
+			default:
+			}
+		}
+	}
+
+	p.logger.Info("心跳间隔已调整", "interval", interval)
+	return &proto.HeartbeatConfigResponse{
+		Success:         true,
+		Message:         "心跳间隔已调整",
+		IntervalSeconds: int32(interval / time.Second),
 	}, nil
 }
 
+// ShutdownReason 获取主机关闭请求的原因代码
+// 未收到关闭请求（如因信号退出）时返回 SHUTDOWN_REASON_UNSPECIFIED；
+// 插件可在清理时据此决定是否持久化状态，例如升级时保存进度供新版本恢复
+func (p *Plugin) ShutdownReason() proto.ShutdownReason {
+	return proto.ShutdownReason(atomic.LoadInt32(&p.shutdownReason))
+}
+
 // 内部方法
 
+// generateID 使用配置的ID生成器生成插件ID
+func (p *Plugin) generateID() string {
+	if p.config.IDGenerator != nil {
+		if id := p.config.IDGenerator(p.config); id != "" {
+			return id
+		}
+		p.logger.Warn("⚠️ ID生成器返回空ID，使用默认生成器")
+	}
+	return DefaultIDGenerator(p.config)
+}
+
 // getFunctionList 获取插件注册的函数列表
 func (p *Plugin) getFunctionList() []string {
 	functions := make([]string, 0, len(p.functions))
@@ -371,43 +1011,95 @@ func (p *Plugin) getFunctionList() []string {
 	return functions
 }
 
+// functionTimeoutsMs 获取声明了超时的函数及其超时（毫秒）
+func (p *Plugin) functionTimeoutsMs() map[string]int64 {
+	if len(p.timeouts) == 0 {
+		return nil
+	}
+	timeouts := make(map[string]int64, len(p.timeouts))
+	for name, timeout := range p.timeouts {
+		timeouts[name] = timeout.Milliseconds()
+	}
+	return timeouts
+}
+
+// listen 按 PortRange 监听TCP端口，未配置时监听随机端口
+func (p *Plugin) listen() (net.Listener, error) {
+	portRange := p.config.PortRange
+	if len(portRange) == 0 {
+		return net.Listen("tcp", ":0")
+	}
+	if len(portRange) != 2 || portRange[0] <= 0 || portRange[0] > portRange[1] {
+		return nil, fmt.Errorf("端口范围配置无效: %v", portRange)
+	}
+
+	listener, _, err := listenTCPRange(portRange[0], portRange[1], p.logger)
+	return listener, err
+}
+
 // startGrpcServer 启动gRPC服务器
 func (p *Plugin) startGrpcServer() error {
-	// 创建监听器，自动分配端口
-	listener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		return err
+	// 创建监听器，未配置端口范围时自动分配端口
+	listener := p.config.Listener
+	if listener == nil {
+		var err error
+		if listener, err = p.listen(); err != nil {
+			return err
+		}
 	}
 
-	// 获取分配的端口
-	addr := listener.Addr().(*net.TCPAddr)
-	p.Port = int32(addr.Port)
+	// 获取分配的端口（非TCP监听器保留预先设置的端口）
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		p.Port = int32(addr.Port)
+	}
 
 	// 创建gRPC服务器
-	p.GrpcServer = grpc.NewServer()
+	p.GrpcServer = grpc.NewServer(p.config.ServerOptions...)
 
 	// 注册插件服务
 	proto.RegisterPluginServiceServer(p.GrpcServer, p)
+	if p.config.EnableReflection {
+		reflection.Register(p.GrpcServer)
+	}
 
 	// 启动服务器
 	go func() {
-		log.Printf("插件gRPC服务器启动在端口: %d", p.Port)
+		p.logger.Info("插件gRPC服务器启动", "port", p.Port)
 		if err := p.GrpcServer.Serve(listener); err != nil {
-			log.Printf("gRPC服务器错误: %v", err)
+			p.logger.Error("gRPC服务器错误", "error", err)
+			if !p.isShuttingDown {
+				p.stopWithError(fmt.Errorf("gRPC服务器错误: %v", err))
+			}
 		}
 	}()
 
+	// 确认服务器已可处理请求后再返回，保证注册后主机回连时插件已就绪
+	return p.waitForServerReady()
+}
+
+// waitForServerReady 通过本地gRPC调用确认插件服务器已开始服务
+func (p *Plugin) waitForServerReady() error {
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", p.Port), grpcDialOptions(p.config.Dialer, p.config.DialOptions)...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := proto.NewPluginServiceClient(conn)
+	if _, err := client.GetPluginStatus(ctx, &proto.StatusRequest{}, grpc.WaitForReady(true)); err != nil {
+		return fmt.Errorf("插件gRPC服务器未就绪: %v", err)
+	}
 	return nil
 }
 
 // connectToHost 连接到主机
 func (p *Plugin) connectToHost() error {
-	log.Printf("连接到主机: %s", p.config.HostAddress)
+	p.logger.Info("连接到主机", "address", p.config.HostAddress)
 
-	conn, err := grpc.Dial(
-		p.config.HostAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := grpc.Dial(p.config.HostAddress, grpcDialOptions(p.config.Dialer, p.config.DialOptions)...)
 	if err != nil {
 		return err
 	}
@@ -418,20 +1110,105 @@ func (p *Plugin) connectToHost() error {
 	return nil
 }
 
+// hostConnection 通过 ConnectHost 建立的额外主机连接
+type hostConnection struct {
+	address string                  // 主机地址
+	conn    *grpc.ClientConn        // gRPC连接
+	client  proto.HostServiceClient // 主机客户端
+}
+
+// ConnectHost 按名称连接一个额外的主机，之后可通过 CallHostFunctionOn 调用其函数
+// 插件仍只向 HostAddress 指定的主机注册、发送心跳；额外主机仅用于调用主机函数
+func (p *Plugin) ConnectHost(name string, address string) error {
+	if name == "" || name == DefaultHostName {
+		return fmt.Errorf("主机名称无效: %q", name)
+	}
+
+	p.hostsMutex.Lock()
+	defer p.hostsMutex.Unlock()
+
+	if _, exists := p.hosts[name]; exists {
+		return fmt.Errorf("主机已连接: %s", name)
+	}
+
+	conn, err := grpc.Dial(address, grpcDialOptions(p.config.Dialer, p.config.DialOptions)...)
+	if err != nil {
+		return fmt.Errorf("连接主机 %s 失败: %v", name, err)
+	}
+
+	p.hosts[name] = &hostConnection{
+		address: address,
+		conn:    conn,
+		client:  proto.NewHostServiceClient(conn),
+	}
+	p.logger.Info("🔗 已连接额外主机", "host", name, "address", address)
+	return nil
+}
+
+// DisconnectHost 断开通过 ConnectHost 连接的主机
+func (p *Plugin) DisconnectHost(name string) error {
+	p.hostsMutex.Lock()
+	host, exists := p.hosts[name]
+	delete(p.hosts, name)
+	p.hostsMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrHostNotConnected, name)
+	}
+
+	p.logger.Info("断开额外主机", "host", name, "address", host.address)
+	return host.conn.Close()
+}
+
+// hostClient 按名称获取主机客户端
+func (p *Plugin) hostClient(name string) (proto.HostServiceClient, error) {
+	if name == "" || name == DefaultHostName {
+		return p.HostClient, nil
+	}
+
+	p.hostsMutex.RLock()
+	defer p.hostsMutex.RUnlock()
+
+	host, exists := p.hosts[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotConnected, name)
+	}
+	return host.client, nil
+}
+
+// closeHosts 关闭所有额外主机连接
+func (p *Plugin) closeHosts() {
+	p.hostsMutex.Lock()
+	defer p.hostsMutex.Unlock()
+
+	for name, host := range p.hosts {
+		host.conn.Close()
+		delete(p.hosts, name)
+	}
+}
+
 // registerToHost 注册到主机
 func (p *Plugin) registerToHost() error {
-	log.Printf("向主机注册插件: %s", p.config.Name)
+	p.logger.Info("向主机注册插件", "plugin_name", p.config.Name, "plugin_id", p.ID)
 
 	req := &proto.RegisterRequest{
-		PluginId:     p.ID,
-		PluginName:   p.config.Name,
-		Version:      p.config.Version,
-		Description:  p.config.Description,
-		Port:         p.Port,
-		Capabilities: p.config.Capabilities,
+		PluginId:              p.ID,
+		PluginName:            p.config.Name,
+		Version:               p.config.Version,
+		Description:           p.config.Description,
+		Port:                  p.Port,
+		Capabilities:          p.config.Capabilities,
+		RequiredHostFunctions: p.config.RequiredHostFunctions,
+		FunctionTimeoutsMs:    p.functionTimeoutsMs(),
+		ProtoVersion:          proto.ProtoVersion,
+		Functions:             p.getFunctionList(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	timeout := p.config.RegisterTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	resp, err := p.HostClient.RegisterPlugin(ctx, req)
@@ -440,13 +1217,50 @@ func (p *Plugin) registerToHost() error {
 	}
 
 	if !resp.Success {
-		return fmt.Errorf("注册失败: %s", resp.Message)
+		return fmt.Errorf("%w: %s", ErrRegisterRejected, resp.Message)
+	}
+	p.callToken.Store(resp.CallToken)
+
+	if resp.ProtoVersion != proto.ProtoVersion {
+		p.logger.Warn("⚠️ 主机协议版本与插件不一致，请同时重新构建主机和插件", "host_proto_version", resp.ProtoVersion, "plugin_proto_version", proto.ProtoVersion)
 	}
 
-	log.Printf("插件注册成功: %s", resp.Message)
+	if len(resp.MissingHostFunctions) > 0 {
+		p.logger.Warn("⚠️ 主机缺少插件依赖的函数", "missing", resp.MissingHostFunctions)
+	}
+
+	p.logger.Info("插件注册成功", "plugin_id", p.ID, "message", resp.Message)
 	return nil
 }
 
+// registerWithRetry 注册到主机，失败时按指数退避重试
+// 主机明确拒绝注册时不再重试
+func (p *Plugin) registerWithRetry() error {
+	interval := p.config.RegisterRetryInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var err error
+	for attempt := 0; attempt <= p.config.RegisterRetries; attempt++ {
+		if attempt > 0 {
+			p.logger.Warn("注册失败，稍后重试", "error", err, "retry_in", interval, "attempt", attempt)
+			select {
+			case <-time.After(interval):
+			case <-p.ctx.Done():
+				return err
+			}
+			interval *= 2
+		}
+
+		err = p.registerToHost()
+		if err == nil || errors.Is(err, ErrRegisterRejected) {
+			return err
+		}
+	}
+	return err
+}
+
 // startHeartbeat 启动心跳
 func (p *Plugin) startHeartbeat() {
 	ticker := time.NewTicker(p.config.HeartbeatInterval)
@@ -456,6 +1270,8 @@ func (p *Plugin) startHeartbeat() {
 		select {
 		case <-p.ctx.Done():
 			return
+		case interval := <-p.heartbeatReset:
+			ticker.Reset(interval)
 		case <-ticker.C:
 			p.sendHeartbeat()
 		}
@@ -468,36 +1284,84 @@ func (p *Plugin) sendHeartbeat() {
 		return
 	}
 
+	req := p.heartbeatRequest()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.heartbeatTimeout())
+	defer cancel()
+
+	resp, err := p.HostClient.Heartbeat(ctx, req)
+	if err != nil {
+		p.logger.Warn("⚠️ 发送心跳失败", "error", err, "reason", describeHeartbeatError(err))
+		return
+	}
+	if !resp.Success {
+		p.logger.Warn("⚠️ 主机未登记本插件", "message", resp.Message)
+	}
+}
+
+// heartbeatRequest 构建心跳请求，状态和健康详情来自 SetHealth 设置的回调
+func (p *Plugin) heartbeatRequest() *proto.HeartbeatRequest {
 	req := &proto.HeartbeatRequest{
 		PluginId:  p.ID,
 		Timestamp: time.Now().Unix(),
-		Status:    "running",
+		Status:    HealthRunning,
+	}
+	if p.healthFunc != nil {
+		status, detail := p.healthFunc()
+		if status != "" {
+			req.Status = status
+		}
+		req.Health = detail
 	}
+	return req
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// heartbeatTimeout 获取心跳RPC超时时间，未配置时默认5秒
+func (p *Plugin) heartbeatTimeout() time.Duration {
+	if p.config.HeartbeatTimeout > 0 {
+		return p.config.HeartbeatTimeout
+	}
+	return 5 * time.Second
+}
 
-	_, err := p.HostClient.Heartbeat(ctx, req)
-	if err != nil {
-		log.Printf("⚠️ 发送心跳失败: %v (主机可能已断开连接)", err)
+// describeHeartbeatError 区分心跳失败的原因，便于判断主机是繁忙还是已断开
+func describeHeartbeatError(err error) string {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return "主机响应超时，可能繁忙"
+	case codes.Unavailable:
+		return "主机不可达，可能已断开连接"
+	default:
+		return "主机可能已断开连接"
 	}
 }
 
 // startConnectionMonitor 启动连接监控器
+// 检测到主机连接持续中断后，根据 CloseOnHostDisconnect 与最大重连次数决定重连或关闭插件
 func (p *Plugin) startConnectionMonitor() {
 	reconnectTries := 0
 	lastHeartbeatSuccess := time.Now()
-	checkInterval := 15 * time.Second
+	disconnected := false
+
+	// 未配置时使用默认的检查间隔和断开阈值
+	checkInterval := p.config.ConnectionCheckInterval
+	if checkInterval <= 0 {
+		checkInterval = 15 * time.Second
+	}
+	disconnectThreshold := p.config.DisconnectThreshold
+	if disconnectThreshold <= 0 {
+		disconnectThreshold = 30 * time.Second
+	}
 
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
-	log.Println("🔍 启动连接监控器...")
+	p.logger.Info("🔍 启动连接监控器...")
 
 	for {
 		select {
 		case <-p.ctx.Done():
-			log.Println("🔍 连接监控器已停止")
+			p.logger.Info("🔍 连接监控器已停止")
 			return
 		case <-ticker.C:
 			if p.isShuttingDown {
@@ -508,54 +1372,102 @@ func (p *Plugin) startConnectionMonitor() {
 			if p.checkConnectionHealth() {
 				lastHeartbeatSuccess = time.Now()
 				reconnectTries = 0
-			} else {
-				if time.Since(lastHeartbeatSuccess) > 30*time.Second {
-					log.Printf("⚠️ 检测到主机连接中断，尝试重连... (第 %d 次)", reconnectTries+1)
-
-					// 如果配置允许重连，则尝试重连
-					if p.maxReconnectTries != 0 { // 0表示无限重连
-						if p.attemptReconnect() {
-							log.Println("✅ 重连主机成功！")
-							lastHeartbeatSuccess = time.Now()
-							reconnectTries = 0
-						} else {
-							reconnectTries++
-							log.Printf("❌ 重连失败，将在 %v 后重试", p.reconnectInterval)
-
-							// 检查是否超过最大重连次数
-							if p.maxReconnectTries > 0 && reconnectTries >= p.maxReconnectTries {
-								log.Printf("❌ 超过最大重连次数 (%d)", p.maxReconnectTries)
-
-								// 根据配置决定是否关闭插件
-								if p.config.CloseOnHostDisconnect {
-									log.Println("🔌 主机连接断开且配置为关闭插件，插件将退出")
-									p.Stop()
-									return
-								} else {
-									log.Println("🔌 主机连接断开但配置为保持运行，插件将继续运行")
-									// 停止心跳和监控，但保持插件运行
-									return
-								}
-							}
-
-							time.Sleep(p.reconnectInterval)
-						}
-					} else {
-						// 无限重连模式
-						if p.attemptReconnect() {
-							log.Println("✅ 重连主机成功！")
-							lastHeartbeatSuccess = time.Now()
-							reconnectTries = 0
-						} else {
-							reconnectTries++
-							log.Printf("❌ 重连失败，将在 %v 后重试", p.reconnectInterval)
-							time.Sleep(p.reconnectInterval)
-						}
-					}
+				if disconnected {
+					disconnected = false
+					p.notifyConnection(p.reconnectFunc)
 				}
+				continue
+			}
+
+			if time.Since(lastHeartbeatSuccess) <= disconnectThreshold {
+				continue
+			}
+
+			if !disconnected {
+				disconnected = true
+				p.notifyConnection(p.disconnectFunc)
+			}
+
+			// 无限重连模式下，配置为主机断开即关闭时直接退出，不再无限重连
+			if p.maxReconnectTries == 0 && p.config.CloseOnHostDisconnect {
+				p.logger.Warn("🔌 主机连接持续中断且配置为关闭插件，插件将退出")
+				p.stopWithError(ErrHostDisconnected)
+				return
 			}
+
+			p.logger.Warn("⚠️ 检测到主机连接中断，尝试重连...", "attempt", reconnectTries+1)
+
+			if p.attemptReconnect() {
+				p.logger.Info("✅ 重连主机成功！")
+				lastHeartbeatSuccess = time.Now()
+				reconnectTries = 0
+				disconnected = false
+				p.notifyConnection(p.reconnectFunc)
+				continue
+			}
+
+			reconnectTries++
+			delay := p.reconnectDelay(reconnectTries)
+			p.logger.Warn("❌ 重连失败，稍后重试", "retry_in", delay)
+
+			// 检查是否超过最大重连次数（0表示无限重连）
+			if p.maxReconnectTries > 0 && reconnectTries >= p.maxReconnectTries {
+				p.logger.Error("❌ 超过最大重连次数", "max_tries", p.maxReconnectTries)
+
+				// 根据配置决定是否关闭插件
+				if p.config.CloseOnHostDisconnect {
+					p.logger.Warn("🔌 主机连接断开且配置为关闭插件，插件将退出")
+					p.stopWithError(fmt.Errorf("%w: 超过最大重连次数 %d", ErrHostDisconnected, p.maxReconnectTries))
+				} else {
+					p.logger.Warn("🔌 主机连接断开但配置为保持运行，插件将继续运行")
+				}
+				// 停止监控，但保持插件运行（或已关闭）
+				return
+			}
+
+			time.Sleep(delay)
+		}
+	}
+}
+
+// notifyConnection 调用连接状态回调，回调panic时记录日志而不影响连接监控
+func (p *Plugin) notifyConnection(fn ConnectionHandler) {
+	if fn == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("连接状态回调发生panic", "panic", r)
+		}
+	}()
+	fn()
+}
+
+// reconnectDelay 计算第n次重连失败后的等待时间
+// 启用指数退避时间隔逐次翻倍（不超过MaxReconnectInterval），并叠加随机抖动
+func (p *Plugin) reconnectDelay(tries int) time.Duration {
+	delay := p.reconnectInterval
+
+	// 指数退避
+	if maxInterval := p.config.MaxReconnectInterval; maxInterval > 0 {
+		for i := 1; i < tries && delay < maxInterval; i++ {
+			delay *= 2
+		}
+		if delay > maxInterval {
+			delay = maxInterval
 		}
 	}
+
+	// 随机抖动，范围为 [1-jitter, 1+jitter]
+	if jitter := p.config.ReconnectJitter; jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		factor := 1 + jitter*(2*rand.Float64()-1)
+		delay = time.Duration(float64(delay) * factor)
+	}
+
+	return delay
 }
 
 // checkConnectionHealth 检查连接健康状态
@@ -564,17 +1476,22 @@ func (p *Plugin) checkConnectionHealth() bool {
 		return false
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), p.heartbeatTimeout())
 	defer cancel()
 
-	req := &proto.HeartbeatRequest{
-		PluginId:  p.ID,
-		Timestamp: time.Now().Unix(),
-		Status:    "running",
-	}
+	req := p.heartbeatRequest()
 
-	_, err := p.HostClient.Heartbeat(ctx, req)
-	return err == nil
+	resp, err := p.HostClient.Heartbeat(ctx, req)
+	if err != nil {
+		p.logger.Warn("⚠️ 连接探测失败", "error", err, "reason", describeHeartbeatError(err))
+		return false
+	}
+	// 主机不再登记本插件（已被停止移除或重启后未接管），按断开处理，由连接监控重新注册或关闭插件
+	if !resp.Success {
+		p.logger.Warn("⚠️ 主机未登记本插件", "message", resp.Message)
+		return false
+	}
+	return true
 }
 
 // attemptReconnect 尝试重新连接主机
@@ -586,15 +1503,18 @@ func (p *Plugin) attemptReconnect() bool {
 		p.HostClient = nil
 	}
 
+	// 主机可能换端口重启，重连前读取最新地址
+	p.refreshHostAddress()
+
 	// 尝试重新连接
 	if err := p.connectToHost(); err != nil {
-		log.Printf("重连失败: %v", err)
+		p.logger.Warn("重连失败", "error", err)
 		return false
 	}
 
 	// 尝试重新注册
 	if err := p.registerToHost(); err != nil {
-		log.Printf("重新注册失败: %v", err)
+		p.logger.Warn("重新注册失败", "error", err)
 		return false
 	}
 
@@ -602,22 +1522,75 @@ func (p *Plugin) attemptReconnect() bool {
 }
 
 // waitForSignal 等待退出信号
+// 插件内部或嵌入程序调用 Stop 时，等待清理完成后返回
 func (p *Plugin) waitForSignal() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
-	<-sigChan
-	log.Println("收到退出信号，开始关闭插件...")
-
-	p.Stop()
+	select {
+	case <-sigChan:
+		p.logger.Info("收到退出信号，开始关闭插件...")
+		p.Stop()
+	case <-p.stopped:
+		p.logger.Info("插件已在内部关闭，退出等待")
+	}
 }
 
 // handleMessage 处理接收到的消息
 func (p *Plugin) handleMessage(msg *proto.MessageRequest) {
-	if p.messageHandler != nil {
-		p.messageHandler(msg)
-	} else {
-		// 默认实现：只是记录日志
-		log.Printf("处理消息: %s", msg.MessageType)
+	p.messageMutex.RLock()
+	handlers := p.messageHandlers
+	p.messageMutex.RUnlock()
+
+	// 默认实现：只是记录日志
+	if len(handlers) == 0 {
+		p.logger.Info("处理消息", "message_type", msg.MessageType, "message_id", msg.MessageId)
+		return
+	}
+	for _, handler := range handlers {
+		handler(msg)
 	}
 }
+
+// callStats 插件函数调用统计
+type callStats struct {
+	count int64         // 调用次数
+	total time.Duration // 累计执行耗时
+	max   time.Duration // 最长执行耗时
+	last  time.Duration // 最近一次执行耗时
+	mutex sync.Mutex    // 统计互斥锁
+}
+
+// record 记录一次调用耗时
+func (cs *callStats) record(elapsed time.Duration) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	cs.count++
+	cs.total += elapsed
+	cs.last = elapsed
+	if elapsed > cs.max {
+		cs.max = elapsed
+	}
+}
+
+// addMetrics 将调用统计写入状态指标（耗时单位为毫秒）
+func (cs *callStats) addMetrics(metrics map[string]string) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	var avg time.Duration
+	if cs.count > 0 {
+		avg = cs.total / time.Duration(cs.count)
+	}
+	metrics["call_count"] = strconv.FormatInt(cs.count, 10)
+	metrics["call_duration_avg_ms"] = formatMillis(avg)
+	metrics["call_duration_max_ms"] = formatMillis(cs.max)
+	metrics["call_duration_last_ms"] = formatMillis(cs.last)
+}
+
+// formatMillis 将耗时格式化为毫秒（保留三位小数）
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
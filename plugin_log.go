@@ -0,0 +1,113 @@
+// Package wwplugin 插件日志上报
+// 缓冲插件日志并批量发送给主机，避免每条日志一次gRPC往返
+package wwplugin
+
+import (
+	"context" // 上下文控制，用于上报超时
+	"sync"    // 同步原语，保护日志缓冲区
+	"time"    // 时间处理，用于定时上报
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// logBuffer 待上报的日志缓冲区
+type logBuffer struct {
+	entries []*proto.LogRequest // 待发送的日志条目
+	dropped int32               // 缓冲区已满被丢弃的条目数
+	mutex   sync.Mutex          // 缓冲区互斥锁
+	flush   chan struct{}       // 立即上报信号 - 缓冲达到批量大小时触发
+}
+
+// Log 记录一条日志并上报给主机
+// 日志先写入缓冲区，按 LogFlushInterval 定期或达到 LogBatchSize 时批量发送；
+// 缓冲区超过 LogBufferSize 时丢弃新日志，丢弃数量随下次上报告知主机
+func (p *Plugin) Log(level LogLevel, msg string) {
+	entry := &proto.LogRequest{
+		PluginId:  p.ID,
+		Level:     proto.LogLevel(level),
+		Message:   msg,
+		Timestamp: time.Now().Unix(),
+	}
+
+	p.logs.mutex.Lock()
+	if limit := p.config.LogBufferSize; limit > 0 && len(p.logs.entries) >= limit {
+		p.logs.dropped++
+		p.logs.mutex.Unlock()
+		return
+	}
+	p.logs.entries = append(p.logs.entries, entry)
+	full := len(p.logs.entries) >= p.logBatchSize()
+	p.logs.mutex.Unlock()
+
+	if full {
+		select {
+		case p.logs.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// startLogFlusher 启动日志定时上报
+func (p *Plugin) startLogFlusher() {
+	interval := p.config.LogFlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.flushLogs()
+		case <-p.logs.flush:
+			p.flushLogs()
+		}
+	}
+}
+
+// flushLogs 将缓冲区中的日志分批发送给主机
+// 发送失败的日志被丢弃，避免主机不可用时缓冲区无限增长
+func (p *Plugin) flushLogs() {
+	p.logs.mutex.Lock()
+	entries := p.logs.entries
+	dropped := p.logs.dropped
+	p.logs.entries = nil
+	p.logs.dropped = 0
+	p.logs.mutex.Unlock()
+
+	if (len(entries) == 0 && dropped == 0) || p.HostClient == nil {
+		return
+	}
+
+	batchSize := p.logBatchSize()
+	for start := 0; start < len(entries) || dropped > 0; start += batchSize {
+		end := start + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := p.HostClient.ReportLogs(ctx, &proto.LogBatchRequest{
+			PluginId: p.ID,
+			Entries:  entries[start:end],
+			Dropped:  dropped,
+		})
+		cancel()
+		if err != nil {
+			p.logger.Warn("上报日志失败", "entries", len(entries)-start, "error", err)
+			return
+		}
+		dropped = 0
+	}
+}
+
+// logBatchSize 获取日志批量大小
+func (p *Plugin) logBatchSize() int {
+	if p.config.LogBatchSize > 0 {
+		return p.config.LogBatchSize
+	}
+	return 100
+}
@@ -0,0 +1,132 @@
+package wwplugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wwwlkj/wwhyplugin/proto"
+)
+
+// TestCloseOnHostDisconnect 主机退出后，开启 CloseOnHostDisconnect 的插件应退出且 Start 返回 ErrHostDisconnected
+func TestCloseOnHostDisconnect(t *testing.T) {
+	th := newTestHost(t)
+
+	config := DefaultPluginConfig("DisconnectPlugin", "1.0.0", "主机断开测试")
+	config.CloseOnHostDisconnect = true
+	config.MaxReconnectTries = 0
+	config.HeartbeatTimeout = 200 * time.Millisecond
+	config.ConnectionCheckInterval = 50 * time.Millisecond
+	config.DisconnectThreshold = 200 * time.Millisecond
+	plugin := NewPlugin(config)
+	th.attachPlugin(plugin)
+
+	result := make(chan error, 1)
+	go func() { result <- plugin.Start() }()
+	if _, err := th.waitPluginReady(plugin.ID, testPluginReadyLimit); err != nil {
+		t.Fatalf("等待插件就绪失败: %v", err)
+	}
+
+	// 直接停止主机的gRPC服务模拟主机进程退出，不向插件发送关闭请求
+	th.grpcServer.Stop()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrHostDisconnected) {
+			t.Fatalf("Start 返回 %v，期望 ErrHostDisconnected", err)
+		}
+	case <-time.After(5 * time.Second):
+		plugin.Stop()
+		t.Fatal("主机退出后插件未退出")
+	}
+}
+
+// TestMessageWorkersProcessedCount 启用工作池时，响应在消息处理完成后返回，ProcessedCount 为已处理的消息数
+func TestMessageWorkersProcessedCount(t *testing.T) {
+	th := newTestHost(t)
+
+	var handled int32
+	plugin := connectTestPlugin(t, th, "MessageWorkerPlugin", func(p *Plugin) {
+		p.config.MessageWorkers = 2
+		p.config.MessageQueueSize = 2
+		p.AddMessageHandler(func(msg *proto.MessageRequest) {
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&handled, 1)
+		})
+	})
+
+	info, _ := th.registry.Get(plugin.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := info.Client.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("创建消息流失败: %v", err)
+	}
+
+	const count = 5
+	for i := 0; i < count; i++ {
+		if err := stream.Send(&proto.MessageRequest{MessageType: "test", Content: "hello"}); err != nil {
+			t.Fatalf("发送消息失败: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("接收响应失败: %v", err)
+	}
+
+	if !resp.Success || resp.ProcessedCount != count {
+		t.Fatalf("响应错误: success=%v processed=%d，期望 success=true processed=%d", resp.Success, resp.ProcessedCount, count)
+	}
+	if got := atomic.LoadInt32(&handled); got != count {
+		t.Fatalf("返回响应时仅处理了 %d 条消息，期望 %d", got, count)
+	}
+}
+
+// TestStartFailureStopsWorkers gRPC服务启动失败时插件停止，已启动的消息工作协程随上下文取消退出
+func TestStartFailureStopsWorkers(t *testing.T) {
+	config := DefaultPluginConfig("BadPortPlugin", "1.0.0", "启动失败测试")
+	config.MessageWorkers = 2
+	config.PortRange = []int{5, 1}
+	plugin := NewPlugin(config)
+
+	if _, err := plugin.StartAsync(); err == nil {
+		t.Fatal("端口范围无效时 StartAsync 应返回错误")
+	}
+	select {
+	case <-plugin.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("启动失败后插件未停止")
+	}
+	if plugin.ctx.Err() == nil {
+		t.Fatal("启动失败后插件上下文未取消，消息工作协程不会退出")
+	}
+}
+
+// TestConfigureHeartbeatConcurrent 并发调整心跳间隔不阻塞，插件停止后调整立即返回失败
+func TestConfigureHeartbeatConcurrent(t *testing.T) {
+	// 插件未启动，心跳循环不会取走调整值
+	plugin := NewPlugin(DefaultPluginConfig("HeartbeatPlugin", "1.0.0", "心跳调整测试"))
+
+	finishWithin(t, 5*time.Second, "并发 ConfigureHeartbeat", func() {
+		var wg sync.WaitGroup
+		for i := 1; i <= 50; i++ {
+			wg.Add(1)
+			go func(seconds int32) {
+				defer wg.Done()
+				plugin.ConfigureHeartbeat(context.Background(), &proto.HeartbeatConfigRequest{IntervalSeconds: seconds})
+			}(int32(i))
+		}
+		wg.Wait()
+	})
+
+	plugin.cancel()
+	finishWithin(t, 5*time.Second, "停止后 ConfigureHeartbeat", func() {
+		resp, err := plugin.ConfigureHeartbeat(context.Background(), &proto.HeartbeatConfigRequest{IntervalSeconds: 1})
+		if err != nil || resp.Success {
+			t.Errorf("插件停止后调整心跳间隔应返回失败: %v %v", resp, err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+// Package wwplugin 插件间调用策略
+// 限制插件可以调用哪些其他插件，未设置策略的插件按 HostConfig.DefaultDenyInterPlugin 处理；
+// 调用方身份由注册时签发的调用凭证确认，插件无法冒用其他插件的ID借用其策略
+package wwplugin
+
+// SetInterPluginPolicy 设置插件可调用的目标插件列表
+// 设置后 sourcePluginID 只能调用 allowedTargets 中的插件，传入空列表表示禁止调用任何插件
+func (ph *PluginHost) SetInterPluginPolicy(sourcePluginID string, allowedTargets []string) {
+	allowed := make(map[string]bool, len(allowedTargets))
+	for _, target := range allowedTargets {
+		allowed[target] = true
+	}
+
+	ph.policyMutex.Lock()
+	defer ph.policyMutex.Unlock()
+	ph.interPluginPolicy[sourcePluginID] = allowed
+}
+
+// ClearInterPluginPolicy 清除插件的调用策略，恢复为主机默认策略
+func (ph *PluginHost) ClearInterPluginPolicy(sourcePluginID string) {
+	ph.policyMutex.Lock()
+	defer ph.policyMutex.Unlock()
+	delete(ph.interPluginPolicy, sourcePluginID)
+}
+
+// AllowedInterPluginTargets 获取插件可调用的目标插件列表
+// ok 为 false 表示该插件未设置策略，按主机默认策略处理
+func (ph *PluginHost) AllowedInterPluginTargets(sourcePluginID string) (targets []string, ok bool) {
+	ph.policyMutex.RLock()
+	defer ph.policyMutex.RUnlock()
+
+	allowed, ok := ph.interPluginPolicy[sourcePluginID]
+	if !ok {
+		return nil, false
+	}
+	targets = make([]string, 0, len(allowed))
+	for target := range allowed {
+		targets = append(targets, target)
+	}
+	return targets, true
+}
+
+// interPluginAllowed 判断源插件是否允许调用目标插件
+func (ph *PluginHost) interPluginAllowed(sourcePluginID string, targetPluginID string) bool {
+	ph.policyMutex.RLock()
+	defer ph.policyMutex.RUnlock()
+
+	allowed, ok := ph.interPluginPolicy[sourcePluginID]
+	if !ok {
+		return !ph.config.DefaultDenyInterPlugin
+	}
+	return allowed[targetPluginID]
+}
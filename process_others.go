@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+// Package wwplugin 插件进程控制 - 非Windows平台
+// 通过SIGTERM请求插件进程优雅退出
+package wwplugin
+
+import (
+	"os"      // 操作系统接口，用于进程控制
+	"syscall" // 系统调用，用于发送终止信号
+)
+
+// terminateProcess 请求进程优雅退出
+// 发送SIGTERM，插件的信号处理会执行Stop清理后退出
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
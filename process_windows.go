@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+// Package wwplugin 插件进程控制 - Windows专用
+// Windows不支持向其他进程发送SIGTERM，由调用方改用Shutdown RPC
+package wwplugin
+
+import (
+	"fmt" // 格式化输出，用于错误信息
+	"os"  // 操作系统接口，用于进程控制
+)
+
+// terminateProcess 请求进程优雅退出
+// Windows上始终返回错误，调用方应回退到Shutdown RPC
+func terminateProcess(process *os.Process) error {
+	return fmt.Errorf("Windows不支持发送终止信号 (PID: %d)", process.Pid)
+}
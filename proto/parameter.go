@@ -0,0 +1,54 @@
+package proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DecodeJSON 将JSON类型参数的值解析到 dest
+// 与 wwplugin.NewJSONParameter 配套使用
+func (x *Parameter) DecodeJSON(dest interface{}) error {
+	if x == nil {
+		return fmt.Errorf("参数为空")
+	}
+	if x.Type != ParameterType_JSON {
+		return fmt.Errorf("参数 %s 不是JSON类型: %s", x.Name, x.Type)
+	}
+	if err := json.Unmarshal([]byte(x.Value), dest); err != nil {
+		return fmt.Errorf("解析参数 %s 失败: %v", x.Name, err)
+	}
+	return nil
+}
+
+// AsTime 将TIMESTAMP类型参数的值解析为时间
+// 与 wwplugin.NewTimeParameter 配套使用，值为RFC3339格式
+func (x *Parameter) AsTime() (time.Time, error) {
+	if x == nil {
+		return time.Time{}, fmt.Errorf("参数为空")
+	}
+	if x.Type != ParameterType_TIMESTAMP {
+		return time.Time{}, fmt.Errorf("参数 %s 不是TIMESTAMP类型: %s", x.Name, x.Type)
+	}
+	t, err := time.Parse(time.RFC3339Nano, x.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("解析参数 %s 失败: %v", x.Name, err)
+	}
+	return t, nil
+}
+
+// AsDuration 将DURATION类型参数的值解析为时长
+// 与 wwplugin.NewDurationParameter 配套使用，值为 time.Duration 文本格式
+func (x *Parameter) AsDuration() (time.Duration, error) {
+	if x == nil {
+		return 0, fmt.Errorf("参数为空")
+	}
+	if x.Type != ParameterType_DURATION {
+		return 0, fmt.Errorf("参数 %s 不是DURATION类型: %s", x.Name, x.Type)
+	}
+	d, err := time.ParseDuration(x.Value)
+	if err != nil {
+		return 0, fmt.Errorf("解析参数 %s 失败: %v", x.Name, err)
+	}
+	return d, nil
+}
@@ -25,12 +25,14 @@ const (
 type ParameterType int32
 
 const (
-	ParameterType_STRING ParameterType = 0
-	ParameterType_INT    ParameterType = 1
-	ParameterType_FLOAT  ParameterType = 2
-	ParameterType_BOOL   ParameterType = 3
-	ParameterType_JSON   ParameterType = 4
-	ParameterType_BYTES  ParameterType = 5
+	ParameterType_STRING    ParameterType = 0
+	ParameterType_INT       ParameterType = 1
+	ParameterType_FLOAT     ParameterType = 2
+	ParameterType_BOOL      ParameterType = 3
+	ParameterType_JSON      ParameterType = 4
+	ParameterType_BYTES     ParameterType = 5
+	ParameterType_TIMESTAMP ParameterType = 6 // RFC3339 格式的时间
+	ParameterType_DURATION  ParameterType = 7 // time.Duration 文本格式的时长，如 "1m30s"
 )
 
 // Enum value maps for ParameterType.
@@ -42,14 +44,18 @@ var (
 		3: "BOOL",
 		4: "JSON",
 		5: "BYTES",
+		6: "TIMESTAMP",
+		7: "DURATION",
 	}
 	ParameterType_value = map[string]int32{
-		"STRING": 0,
-		"INT":    1,
-		"FLOAT":  2,
-		"BOOL":   3,
-		"JSON":   4,
-		"BYTES":  5,
+		"STRING":    0,
+		"INT":       1,
+		"FLOAT":     2,
+		"BOOL":      3,
+		"JSON":      4,
+		"BYTES":     5,
+		"TIMESTAMP": 6,
+		"DURATION":  7,
 	}
 )
 
@@ -133,17 +139,74 @@ func (LogLevel) EnumDescriptor() ([]byte, []int) {
 	return file_proto_plugin_proto_rawDescGZIP(), []int{1}
 }
 
+// 关闭原因代码
+type ShutdownReason int32
+
+const (
+	ShutdownReason_SHUTDOWN_REASON_UNSPECIFIED   ShutdownReason = 0 // 未指定
+	ShutdownReason_SHUTDOWN_REASON_HOST_SHUTDOWN ShutdownReason = 1 // 主机正在关闭
+	ShutdownReason_SHUTDOWN_REASON_OPERATOR      ShutdownReason = 2 // 操作人员主动停止插件
+	ShutdownReason_SHUTDOWN_REASON_UPGRADE       ShutdownReason = 3 // 插件升级，新版本即将启动
+)
+
+// Enum value maps for ShutdownReason.
+var (
+	ShutdownReason_name = map[int32]string{
+		0: "SHUTDOWN_REASON_UNSPECIFIED",
+		1: "SHUTDOWN_REASON_HOST_SHUTDOWN",
+		2: "SHUTDOWN_REASON_OPERATOR",
+		3: "SHUTDOWN_REASON_UPGRADE",
+	}
+	ShutdownReason_value = map[string]int32{
+		"SHUTDOWN_REASON_UNSPECIFIED":   0,
+		"SHUTDOWN_REASON_HOST_SHUTDOWN": 1,
+		"SHUTDOWN_REASON_OPERATOR":      2,
+		"SHUTDOWN_REASON_UPGRADE":       3,
+	}
+)
+
+func (x ShutdownReason) Enum() *ShutdownReason {
+	p := new(ShutdownReason)
+	*p = x
+	return p
+}
+
+func (x ShutdownReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ShutdownReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_plugin_proto_enumTypes[2].Descriptor()
+}
+
+func (ShutdownReason) Type() protoreflect.EnumType {
+	return &file_proto_plugin_proto_enumTypes[2]
+}
+
+func (x ShutdownReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ShutdownReason.Descriptor instead.
+func (ShutdownReason) EnumDescriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{2}
+}
+
 // 插件注册请求
 type RegisterRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	PluginId      string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`       // 插件唯一标识
-	PluginName    string                 `protobuf:"bytes,2,opt,name=plugin_name,json=pluginName,proto3" json:"plugin_name,omitempty"` // 插件名称
-	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`                         // 插件版本
-	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`                 // 插件描述
-	Port          int32                  `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`                              // 插件gRPC服务端口
-	Capabilities  []string               `protobuf:"bytes,6,rep,name=capabilities,proto3" json:"capabilities,omitempty"`               // 插件能力列表
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	PluginId              string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`                                                                                                            // 插件唯一标识
+	PluginName            string                 `protobuf:"bytes,2,opt,name=plugin_name,json=pluginName,proto3" json:"plugin_name,omitempty"`                                                                                                      // 插件名称
+	Version               string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`                                                                                                                              // 插件版本
+	Description           string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`                                                                                                                      // 插件描述
+	Port                  int32                  `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`                                                                                                                                   // 插件gRPC服务端口
+	Capabilities          []string               `protobuf:"bytes,6,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                                                                                                                    // 插件能力列表
+	RequiredHostFunctions []string               `protobuf:"bytes,7,rep,name=required_host_functions,json=requiredHostFunctions,proto3" json:"required_host_functions,omitempty"`                                                                   // 插件依赖的主机函数
+	FunctionTimeoutsMs    map[string]int64       `protobuf:"bytes,8,rep,name=function_timeouts_ms,json=functionTimeoutsMs,proto3" json:"function_timeouts_ms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 函数级调用超时（毫秒），未声明的函数使用主机默认超时
+	ProtoVersion          int32                  `protobuf:"varint,9,opt,name=proto_version,json=protoVersion,proto3" json:"proto_version,omitempty"`                                                                                               // 插件构建时的协议版本，0表示早于版本握手的插件
+	Functions             []string               `protobuf:"bytes,10,rep,name=functions,proto3" json:"functions,omitempty"`                                                                                                                         // 插件当前注册的函数列表，用于刷新主机记录的函数列表
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *RegisterRequest) Reset() {
@@ -218,14 +281,45 @@ func (x *RegisterRequest) GetCapabilities() []string {
 	return nil
 }
 
+func (x *RegisterRequest) GetRequiredHostFunctions() []string {
+	if x != nil {
+		return x.RequiredHostFunctions
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetFunctionTimeoutsMs() map[string]int64 {
+	if x != nil {
+		return x.FunctionTimeoutsMs
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetProtoVersion() int32 {
+	if x != nil {
+		return x.ProtoVersion
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetFunctions() []string {
+	if x != nil {
+		return x.Functions
+	}
+	return nil
+}
+
 // 插件注册响应
 type RegisterResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	HostId        string                 `protobuf:"bytes,3,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"` // 主程序分配的ID
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Success              bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message              string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	HostId               string                 `protobuf:"bytes,3,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`                                             // 主程序分配的ID
+	MissingHostFunctions []string               `protobuf:"bytes,4,rep,name=missing_host_functions,json=missingHostFunctions,proto3" json:"missing_host_functions,omitempty"` // 主程序缺少的依赖函数
+	ProtoVersion         int32                  `protobuf:"varint,5,opt,name=proto_version,json=protoVersion,proto3" json:"proto_version,omitempty"`                          // 主程序构建时的协议版本
+	CallToken            string                 `protobuf:"bytes,6,opt,name=call_token,json=callToken,proto3" json:"call_token,omitempty"`                                    // 主程序签发的调用凭证，插件间调用时携带以证明调用方身份
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *RegisterResponse) Reset() {
@@ -279,12 +373,34 @@ func (x *RegisterResponse) GetHostId() string {
 	return ""
 }
 
+func (x *RegisterResponse) GetMissingHostFunctions() []string {
+	if x != nil {
+		return x.MissingHostFunctions
+	}
+	return nil
+}
+
+func (x *RegisterResponse) GetProtoVersion() int32 {
+	if x != nil {
+		return x.ProtoVersion
+	}
+	return 0
+}
+
+func (x *RegisterResponse) GetCallToken() string {
+	if x != nil {
+		return x.CallToken
+	}
+	return ""
+}
+
 // 心跳请求
 type HeartbeatRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PluginId      string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
 	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // running, idle, busy, error
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                                                                           // running, idle, busy, degraded, error
+	Health        map[string]string      `protobuf:"bytes,4,rep,name=health,proto3" json:"health,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 健康详情，如依赖服务的连接状态
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -340,6 +456,13 @@ func (x *HeartbeatRequest) GetStatus() string {
 	return ""
 }
 
+func (x *HeartbeatRequest) GetHealth() map[string]string {
+	if x != nil {
+		return x.Health
+	}
+	return nil
+}
+
 // 心跳响应
 type HeartbeatResponse struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
@@ -475,9 +598,10 @@ type CallResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Result        *Parameter             `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`                        // 返回结果
-	ErrorCode     string                 `protobuf:"bytes,4,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"` // 错误码
-	RequestId     string                 `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // 对应的请求ID
+	Result        *Parameter             `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`                                                                               // 返回结果
+	ErrorCode     string                 `protobuf:"bytes,4,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`                                                        // 错误码
+	RequestId     string                 `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                                                        // 对应的请求ID
+	Metadata      map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 响应元数据，如插件间调用中实际处理调用的插件
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -547,6 +671,13 @@ func (x *CallResponse) GetRequestId() string {
 	return ""
 }
 
+func (x *CallResponse) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 // 参数定义
 type Parameter struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -685,6 +816,67 @@ func (x *LogRequest) GetCategory() string {
 	return ""
 }
 
+// 批量日志请求
+type LogBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PluginId      string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"` // 插件ID
+	Entries       []*LogRequest          `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`                   // 日志条目（按产生顺序）
+	Dropped       int32                  `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`                  // 因缓冲区已满被丢弃的条目数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatchRequest) Reset() {
+	*x = LogBatchRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatchRequest) ProtoMessage() {}
+
+func (x *LogBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatchRequest.ProtoReflect.Descriptor instead.
+func (*LogBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogBatchRequest) GetPluginId() string {
+	if x != nil {
+		return x.PluginId
+	}
+	return ""
+}
+
+func (x *LogBatchRequest) GetEntries() []*LogRequest {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *LogBatchRequest) GetDropped() int32 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
 // 日志响应
 type LogResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -695,7 +887,7 @@ type LogResponse struct {
 
 func (x *LogResponse) Reset() {
 	*x = LogResponse{}
-	mi := &file_proto_plugin_proto_msgTypes[8]
+	mi := &file_proto_plugin_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -707,7 +899,7 @@ func (x *LogResponse) String() string {
 func (*LogResponse) ProtoMessage() {}
 
 func (x *LogResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[8]
+	mi := &file_proto_plugin_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -720,7 +912,7 @@ func (x *LogResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogResponse.ProtoReflect.Descriptor instead.
 func (*LogResponse) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{8}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *LogResponse) GetSuccess() bool {
@@ -744,7 +936,7 @@ type MessageRequest struct {
 
 func (x *MessageRequest) Reset() {
 	*x = MessageRequest{}
-	mi := &file_proto_plugin_proto_msgTypes[9]
+	mi := &file_proto_plugin_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -756,7 +948,7 @@ func (x *MessageRequest) String() string {
 func (*MessageRequest) ProtoMessage() {}
 
 func (x *MessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[9]
+	mi := &file_proto_plugin_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -769,7 +961,7 @@ func (x *MessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MessageRequest.ProtoReflect.Descriptor instead.
 func (*MessageRequest) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{9}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *MessageRequest) GetMessageId() string {
@@ -819,7 +1011,7 @@ type MessageResponse struct {
 
 func (x *MessageResponse) Reset() {
 	*x = MessageResponse{}
-	mi := &file_proto_plugin_proto_msgTypes[10]
+	mi := &file_proto_plugin_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -831,7 +1023,7 @@ func (x *MessageResponse) String() string {
 func (*MessageResponse) ProtoMessage() {}
 
 func (x *MessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[10]
+	mi := &file_proto_plugin_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,7 +1036,7 @@ func (x *MessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MessageResponse.ProtoReflect.Descriptor instead.
 func (*MessageResponse) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{10}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *MessageResponse) GetSuccess() bool {
@@ -878,7 +1070,7 @@ type StatusRequest struct {
 
 func (x *StatusRequest) Reset() {
 	*x = StatusRequest{}
-	mi := &file_proto_plugin_proto_msgTypes[11]
+	mi := &file_proto_plugin_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -890,7 +1082,7 @@ func (x *StatusRequest) String() string {
 func (*StatusRequest) ProtoMessage() {}
 
 func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[11]
+	mi := &file_proto_plugin_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -903,7 +1095,7 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
 func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{11}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *StatusRequest) GetIncludeMetrics() bool {
@@ -926,7 +1118,7 @@ type StatusResponse struct {
 
 func (x *StatusResponse) Reset() {
 	*x = StatusResponse{}
-	mi := &file_proto_plugin_proto_msgTypes[12]
+	mi := &file_proto_plugin_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -938,7 +1130,7 @@ func (x *StatusResponse) String() string {
 func (*StatusResponse) ProtoMessage() {}
 
 func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[12]
+	mi := &file_proto_plugin_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -951,7 +1143,7 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
 func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{12}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *StatusResponse) GetStatus() string {
@@ -985,16 +1177,17 @@ func (x *StatusResponse) GetActiveFunctions() []string {
 // 关闭请求
 type ShutdownRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	Force          bool                   `protobuf:"varint,1,opt,name=force,proto3" json:"force,omitempty"`                                         // 是否强制关闭
-	TimeoutSeconds int32                  `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // 关闭超时时间
-	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                                        // 关闭原因
+	Force          bool                   `protobuf:"varint,1,opt,name=force,proto3" json:"force,omitempty"`                                                          // 是否强制关闭
+	TimeoutSeconds int32                  `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`                  // 关闭超时时间
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                                                         // 关闭原因
+	ReasonCode     ShutdownReason         `protobuf:"varint,4,opt,name=reason_code,json=reasonCode,proto3,enum=wwplugin.ShutdownReason" json:"reason_code,omitempty"` // 关闭原因代码，供插件按原因决定关闭行为
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ShutdownRequest) Reset() {
 	*x = ShutdownRequest{}
-	mi := &file_proto_plugin_proto_msgTypes[13]
+	mi := &file_proto_plugin_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1006,7 +1199,7 @@ func (x *ShutdownRequest) String() string {
 func (*ShutdownRequest) ProtoMessage() {}
 
 func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[13]
+	mi := &file_proto_plugin_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1019,7 +1212,7 @@ func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShutdownRequest.ProtoReflect.Descriptor instead.
 func (*ShutdownRequest) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{13}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ShutdownRequest) GetForce() bool {
@@ -1043,18 +1236,26 @@ func (x *ShutdownRequest) GetReason() string {
 	return ""
 }
 
+func (x *ShutdownRequest) GetReasonCode() ShutdownReason {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ShutdownReason_SHUTDOWN_REASON_UNSPECIFIED
+}
+
 // 关闭响应
 type ShutdownResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // false表示插件拒绝关闭，message说明原因
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	GraceSeconds  int32                  `protobuf:"varint,3,opt,name=grace_seconds,json=graceSeconds,proto3" json:"grace_seconds,omitempty"` // 插件请求的退出宽限时间（秒），0表示使用主机默认值
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ShutdownResponse) Reset() {
 	*x = ShutdownResponse{}
-	mi := &file_proto_plugin_proto_msgTypes[14]
+	mi := &file_proto_plugin_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1066,7 +1267,7 @@ func (x *ShutdownResponse) String() string {
 func (*ShutdownResponse) ProtoMessage() {}
 
 func (x *ShutdownResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_proto_msgTypes[14]
+	mi := &file_proto_plugin_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1079,7 +1280,7 @@ func (x *ShutdownResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShutdownResponse.ProtoReflect.Descriptor instead.
 func (*ShutdownResponse) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_proto_rawDescGZIP(), []int{14}
+	return file_proto_plugin_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ShutdownResponse) GetSuccess() bool {
@@ -1096,117 +1297,686 @@ func (x *ShutdownResponse) GetMessage() string {
 	return ""
 }
 
-var File_proto_plugin_proto protoreflect.FileDescriptor
+func (x *ShutdownResponse) GetGraceSeconds() int32 {
+	if x != nil {
+		return x.GraceSeconds
+	}
+	return 0
+}
 
-const file_proto_plugin_proto_rawDesc = "" +
-	"\n" +
-	"\x12proto/plugin.proto\x12\bwwplugin\"\xc3\x01\n" +
-	"\x0fRegisterRequest\x12\x1b\n" +
-	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12\x1f\n" +
-	"\vplugin_name\x18\x02 \x01(\tR\n" +
-	"pluginName\x12\x18\n" +
-	"\aversion\x18\x03 \x01(\tR\aversion\x12 \n" +
-	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x12\n" +
-	"\x04port\x18\x05 \x01(\x05R\x04port\x12\"\n" +
-	"\fcapabilities\x18\x06 \x03(\tR\fcapabilities\"_\n" +
-	"\x10RegisterResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12\x17\n" +
-	"\ahost_id\x18\x03 \x01(\tR\x06hostId\"e\n" +
-	"\x10HeartbeatRequest\x12\x1b\n" +
-	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12\x1c\n" +
-	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\tR\x06status\"r\n" +
-	"\x11HeartbeatResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12)\n" +
-	"\x10server_timestamp\x18\x03 \x01(\x03R\x0fserverTimestamp\"\x84\x02\n" +
-	"\vCallRequest\x12#\n" +
-	"\rfunction_name\x18\x01 \x01(\tR\ffunctionName\x123\n" +
-	"\n" +
-	"parameters\x18\x02 \x03(\v2\x13.wwplugin.ParameterR\n" +
-	"parameters\x12?\n" +
-	"\bmetadata\x18\x03 \x03(\v2#.wwplugin.CallRequest.MetadataEntryR\bmetadata\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x04 \x01(\tR\trequestId\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xad\x01\n" +
-	"\fCallResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12+\n" +
-	"\x06result\x18\x03 \x01(\v2\x13.wwplugin.ParameterR\x06result\x12\x1d\n" +
-	"\n" +
-	"error_code\x18\x04 \x01(\tR\terrorCode\x12\x1d\n" +
-	"\n" +
-	"request_id\x18\x05 \x01(\tR\trequestId\"b\n" +
-	"\tParameter\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
-	"\x04type\x18\x02 \x01(\x0e2\x17.wwplugin.ParameterTypeR\x04type\x12\x14\n" +
-	"\x05value\x18\x03 \x01(\tR\x05value\"\xa7\x01\n" +
-	"\n" +
-	"LogRequest\x12\x1b\n" +
-	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12(\n" +
-	"\x05level\x18\x02 \x01(\x0e2\x12.wwplugin.LogLevelR\x05level\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x1a\n" +
-	"\bcategory\x18\x05 \x01(\tR\bcategory\"'\n" +
-	"\vLogResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x8b\x02\n" +
-	"\x0eMessageRequest\x12\x1d\n" +
-	"\n" +
-	"message_id\x18\x01 \x01(\tR\tmessageId\x12!\n" +
-	"\fmessage_type\x18\x02 \x01(\tR\vmessageType\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12B\n" +
-	"\bmetadata\x18\x05 \x03(\v2&.wwplugin.MessageRequest.MetadataEntryR\bmetadata\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"n\n" +
-	"\x0fMessageResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
-	"\x0fprocessed_count\x18\x03 \x01(\x05R\x0eprocessedCount\"8\n" +
-	"\rStatusRequest\x12'\n" +
-	"\x0finclude_metrics\x18\x01 \x01(\bR\x0eincludeMetrics\"\xe8\x01\n" +
-	"\x0eStatusResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
-	"\x06uptime\x18\x02 \x01(\tR\x06uptime\x12?\n" +
-	"\ametrics\x18\x03 \x03(\v2%.wwplugin.StatusResponse.MetricsEntryR\ametrics\x12)\n" +
-	"\x10active_functions\x18\x04 \x03(\tR\x0factiveFunctions\x1a:\n" +
-	"\fMetricsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"h\n" +
-	"\x0fShutdownRequest\x12\x14\n" +
-	"\x05force\x18\x01 \x01(\bR\x05force\x12'\n" +
-	"\x0ftimeout_seconds\x18\x02 \x01(\x05R\x0etimeoutSeconds\x12\x16\n" +
-	"\x06reason\x18\x03 \x01(\tR\x06reason\"F\n" +
-	"\x10ShutdownResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage*N\n" +
-	"\rParameterType\x12\n" +
-	"\n" +
-	"\x06STRING\x10\x00\x12\a\n" +
-	"\x03INT\x10\x01\x12\t\n" +
-	"\x05FLOAT\x10\x02\x12\b\n" +
-	"\x04BOOL\x10\x03\x12\b\n" +
-	"\x04JSON\x10\x04\x12\t\n" +
-	"\x05BYTES\x10\x05*4\n" +
-	"\bLogLevel\x12\t\n" +
+// 配置推送请求
+type ConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        map[string]string      `protobuf:"bytes,1,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 配置项，如日志级别、功能开关
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigRequest) Reset() {
+	*x = ConfigRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigRequest) ProtoMessage() {}
+
+func (x *ConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigRequest.ProtoReflect.Descriptor instead.
+func (*ConfigRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ConfigRequest) GetConfig() map[string]string {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+// 配置推送响应
+type ConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigResponse) Reset() {
+	*x = ConfigResponse{}
+	mi := &file_proto_plugin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigResponse) ProtoMessage() {}
+
+func (x *ConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigResponse.ProtoReflect.Descriptor instead.
+func (*ConfigResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ConfigResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ConfigResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// 能力更新请求
+type CapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PluginId      string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"` // 插件ID
+	Capabilities  []string               `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`         // 最新的能力列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesRequest) Reset() {
+	*x = CapabilitiesRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesRequest) ProtoMessage() {}
+
+func (x *CapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CapabilitiesRequest) GetPluginId() string {
+	if x != nil {
+		return x.PluginId
+	}
+	return ""
+}
+
+func (x *CapabilitiesRequest) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+// 能力更新响应
+type CapabilitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesResponse) Reset() {
+	*x = CapabilitiesResponse{}
+	mi := &file_proto_plugin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesResponse) ProtoMessage() {}
+
+func (x *CapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CapabilitiesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CapabilitiesResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// 函数列表查询请求
+type ListFunctionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PluginId      string                 `protobuf:"bytes,1,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"` // 查询方插件ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFunctionsRequest) Reset() {
+	*x = ListFunctionsRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFunctionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFunctionsRequest) ProtoMessage() {}
+
+func (x *ListFunctionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFunctionsRequest.ProtoReflect.Descriptor instead.
+func (*ListFunctionsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListFunctionsRequest) GetPluginId() string {
+	if x != nil {
+		return x.PluginId
+	}
+	return ""
+}
+
+// 函数列表查询响应
+type ListFunctionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FunctionNames []string               `protobuf:"bytes,1,rep,name=function_names,json=functionNames,proto3" json:"function_names,omitempty"` // 已注册的函数名称（按名称排序）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFunctionsResponse) Reset() {
+	*x = ListFunctionsResponse{}
+	mi := &file_proto_plugin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFunctionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFunctionsResponse) ProtoMessage() {}
+
+func (x *ListFunctionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFunctionsResponse.ProtoReflect.Descriptor instead.
+func (*ListFunctionsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListFunctionsResponse) GetFunctionNames() []string {
+	if x != nil {
+		return x.FunctionNames
+	}
+	return nil
+}
+
+// 回显请求
+type EchoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       string                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"` // 回显内容，原样返回
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EchoRequest) Reset() {
+	*x = EchoRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EchoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoRequest) ProtoMessage() {}
+
+func (x *EchoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoRequest.ProtoReflect.Descriptor instead.
+func (*EchoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *EchoRequest) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+// 回显响应
+type EchoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       string                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"` // 请求中的回显内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EchoResponse) Reset() {
+	*x = EchoResponse{}
+	mi := &file_proto_plugin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EchoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoResponse) ProtoMessage() {}
+
+func (x *EchoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoResponse.ProtoReflect.Descriptor instead.
+func (*EchoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EchoResponse) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+// 心跳间隔调整请求
+type HeartbeatConfigRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntervalSeconds int32                  `protobuf:"varint,1,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"` // 新的心跳间隔（秒），0表示恢复插件配置的间隔
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HeartbeatConfigRequest) Reset() {
+	*x = HeartbeatConfigRequest{}
+	mi := &file_proto_plugin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatConfigRequest) ProtoMessage() {}
+
+func (x *HeartbeatConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatConfigRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatConfigRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HeartbeatConfigRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+// 心跳间隔调整响应
+type HeartbeatConfigResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message         string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	IntervalSeconds int32                  `protobuf:"varint,3,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"` // 调整后实际生效的心跳间隔（秒）
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HeartbeatConfigResponse) Reset() {
+	*x = HeartbeatConfigResponse{}
+	mi := &file_proto_plugin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatConfigResponse) ProtoMessage() {}
+
+func (x *HeartbeatConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatConfigResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatConfigResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *HeartbeatConfigResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *HeartbeatConfigResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HeartbeatConfigResponse) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+var File_proto_plugin_proto protoreflect.FileDescriptor
+
+const file_proto_plugin_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/plugin.proto\x12\bwwplugin\"\xea\x03\n" +
+	"\x0fRegisterRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12\x1f\n" +
+	"\vplugin_name\x18\x02 \x01(\tR\n" +
+	"pluginName\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04port\x18\x05 \x01(\x05R\x04port\x12\"\n" +
+	"\fcapabilities\x18\x06 \x03(\tR\fcapabilities\x126\n" +
+	"\x17required_host_functions\x18\a \x03(\tR\x15requiredHostFunctions\x12c\n" +
+	"\x14function_timeouts_ms\x18\b \x03(\v21.wwplugin.RegisterRequest.FunctionTimeoutsMsEntryR\x12functionTimeoutsMs\x12#\n" +
+	"\rproto_version\x18\t \x01(\x05R\fprotoVersion\x12\x1c\n" +
+	"\tfunctions\x18\n" +
+	" \x03(\tR\tfunctions\x1aE\n" +
+	"\x17FunctionTimeoutsMsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xd9\x01\n" +
+	"\x10RegisterResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x17\n" +
+	"\ahost_id\x18\x03 \x01(\tR\x06hostId\x124\n" +
+	"\x16missing_host_functions\x18\x04 \x03(\tR\x14missingHostFunctions\x12#\n" +
+	"\rproto_version\x18\x05 \x01(\x05R\fprotoVersion\x12\x1d\n" +
+	"\n" +
+	"call_token\x18\x06 \x01(\tR\tcallToken\"\xe0\x01\n" +
+	"\x10HeartbeatRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12>\n" +
+	"\x06health\x18\x04 \x03(\v2&.wwplugin.HeartbeatRequest.HealthEntryR\x06health\x1a9\n" +
+	"\vHealthEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"r\n" +
+	"\x11HeartbeatResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12)\n" +
+	"\x10server_timestamp\x18\x03 \x01(\x03R\x0fserverTimestamp\"\x84\x02\n" +
+	"\vCallRequest\x12#\n" +
+	"\rfunction_name\x18\x01 \x01(\tR\ffunctionName\x123\n" +
+	"\n" +
+	"parameters\x18\x02 \x03(\v2\x13.wwplugin.ParameterR\n" +
+	"parameters\x12?\n" +
+	"\bmetadata\x18\x03 \x03(\v2#.wwplugin.CallRequest.MetadataEntryR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xac\x02\n" +
+	"\fCallResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12+\n" +
+	"\x06result\x18\x03 \x01(\v2\x13.wwplugin.ParameterR\x06result\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x04 \x01(\tR\terrorCode\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x05 \x01(\tR\trequestId\x12@\n" +
+	"\bmetadata\x18\x06 \x03(\v2$.wwplugin.CallResponse.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"b\n" +
+	"\tParameter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x17.wwplugin.ParameterTypeR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"\xa7\x01\n" +
+	"\n" +
+	"LogRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12(\n" +
+	"\x05level\x18\x02 \x01(\x0e2\x12.wwplugin.LogLevelR\x05level\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\"x\n" +
+	"\x0fLogBatchRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12.\n" +
+	"\aentries\x18\x02 \x03(\v2\x14.wwplugin.LogRequestR\aentries\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\x05R\adropped\"'\n" +
+	"\vLogResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x8b\x02\n" +
+	"\x0eMessageRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12!\n" +
+	"\fmessage_type\x18\x02 \x01(\tR\vmessageType\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12B\n" +
+	"\bmetadata\x18\x05 \x03(\v2&.wwplugin.MessageRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"n\n" +
+	"\x0fMessageResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
+	"\x0fprocessed_count\x18\x03 \x01(\x05R\x0eprocessedCount\"8\n" +
+	"\rStatusRequest\x12'\n" +
+	"\x0finclude_metrics\x18\x01 \x01(\bR\x0eincludeMetrics\"\xe8\x01\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06uptime\x18\x02 \x01(\tR\x06uptime\x12?\n" +
+	"\ametrics\x18\x03 \x03(\v2%.wwplugin.StatusResponse.MetricsEntryR\ametrics\x12)\n" +
+	"\x10active_functions\x18\x04 \x03(\tR\x0factiveFunctions\x1a:\n" +
+	"\fMetricsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa3\x01\n" +
+	"\x0fShutdownRequest\x12\x14\n" +
+	"\x05force\x18\x01 \x01(\bR\x05force\x12'\n" +
+	"\x0ftimeout_seconds\x18\x02 \x01(\x05R\x0etimeoutSeconds\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x129\n" +
+	"\vreason_code\x18\x04 \x01(\x0e2\x18.wwplugin.ShutdownReasonR\n" +
+	"reasonCode\"k\n" +
+	"\x10ShutdownResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12#\n" +
+	"\rgrace_seconds\x18\x03 \x01(\x05R\fgraceSeconds\"\x87\x01\n" +
+	"\rConfigRequest\x12;\n" +
+	"\x06config\x18\x01 \x03(\v2#.wwplugin.ConfigRequest.ConfigEntryR\x06config\x1a9\n" +
+	"\vConfigEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"D\n" +
+	"\x0eConfigResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"V\n" +
+	"\x13CapabilitiesRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\x12\"\n" +
+	"\fcapabilities\x18\x02 \x03(\tR\fcapabilities\"J\n" +
+	"\x14CapabilitiesResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"3\n" +
+	"\x14ListFunctionsRequest\x12\x1b\n" +
+	"\tplugin_id\x18\x01 \x01(\tR\bpluginId\">\n" +
+	"\x15ListFunctionsResponse\x12%\n" +
+	"\x0efunction_names\x18\x01 \x03(\tR\rfunctionNames\"'\n" +
+	"\vEchoRequest\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\tR\apayload\"(\n" +
+	"\fEchoResponse\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\tR\apayload\"C\n" +
+	"\x16HeartbeatConfigRequest\x12)\n" +
+	"\x10interval_seconds\x18\x01 \x01(\x05R\x0fintervalSeconds\"x\n" +
+	"\x17HeartbeatConfigResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12)\n" +
+	"\x10interval_seconds\x18\x03 \x01(\x05R\x0fintervalSeconds*k\n" +
+	"\rParameterType\x12\n" +
+	"\n" +
+	"\x06STRING\x10\x00\x12\a\n" +
+	"\x03INT\x10\x01\x12\t\n" +
+	"\x05FLOAT\x10\x02\x12\b\n" +
+	"\x04BOOL\x10\x03\x12\b\n" +
+	"\x04JSON\x10\x04\x12\t\n" +
+	"\x05BYTES\x10\x05\x12\r\n" +
+	"\tTIMESTAMP\x10\x06\x12\f\n" +
+	"\bDURATION\x10\a*4\n" +
+	"\bLogLevel\x12\t\n" +
 	"\x05DEBUG\x10\x00\x12\b\n" +
 	"\x04INFO\x10\x01\x12\b\n" +
 	"\x04WARN\x10\x02\x12\t\n" +
-	"\x05ERROR\x10\x032\x99\x02\n" +
+	"\x05ERROR\x10\x03*\x8f\x01\n" +
+	"\x0eShutdownReason\x12\x1f\n" +
+	"\x1bSHUTDOWN_REASON_UNSPECIFIED\x10\x00\x12!\n" +
+	"\x1dSHUTDOWN_REASON_HOST_SHUTDOWN\x10\x01\x12\x1c\n" +
+	"\x18SHUTDOWN_REASON_OPERATOR\x10\x02\x12\x1b\n" +
+	"\x17SHUTDOWN_REASON_UPGRADE\x10\x032\x86\x05\n" +
 	"\vHostService\x12G\n" +
 	"\x0eRegisterPlugin\x12\x19.wwplugin.RegisterRequest\x1a\x1a.wwplugin.RegisterResponse\x12D\n" +
 	"\tHeartbeat\x12\x1a.wwplugin.HeartbeatRequest\x1a\x1b.wwplugin.HeartbeatResponse\x12A\n" +
-	"\x10CallHostFunction\x12\x15.wwplugin.CallRequest\x1a\x16.wwplugin.CallResponse\x128\n" +
-	"\tReportLog\x12\x14.wwplugin.LogRequest\x1a\x15.wwplugin.LogResponse2\xa7\x02\n" +
+	"\x10CallHostFunction\x12\x15.wwplugin.CallRequest\x1a\x16.wwplugin.CallResponse\x12I\n" +
+	"\x16CallHostFunctionStream\x12\x15.wwplugin.CallRequest\x1a\x16.wwplugin.CallResponse0\x01\x128\n" +
+	"\tReportLog\x12\x14.wwplugin.LogRequest\x1a\x15.wwplugin.LogResponse\x12>\n" +
+	"\n" +
+	"ReportLogs\x12\x19.wwplugin.LogBatchRequest\x1a\x15.wwplugin.LogResponse\x12S\n" +
+	"\x12UpdateCapabilities\x12\x1d.wwplugin.CapabilitiesRequest\x1a\x1e.wwplugin.CapabilitiesResponse\x12T\n" +
+	"\x11ListHostFunctions\x12\x1e.wwplugin.ListFunctionsRequest\x1a\x1f.wwplugin.ListFunctionsResponse\x125\n" +
+	"\x04Echo\x12\x15.wwplugin.EchoRequest\x1a\x16.wwplugin.EchoResponse2\xfa\x03\n" +
 	"\rPluginService\x12C\n" +
 	"\x12CallPluginFunction\x12\x15.wwplugin.CallRequest\x1a\x16.wwplugin.CallResponse\x12H\n" +
 	"\x0fReceiveMessages\x12\x18.wwplugin.MessageRequest\x1a\x19.wwplugin.MessageResponse(\x01\x12D\n" +
 	"\x0fGetPluginStatus\x12\x17.wwplugin.StatusRequest\x1a\x18.wwplugin.StatusResponse\x12A\n" +
-	"\bShutdown\x12\x19.wwplugin.ShutdownRequest\x1a\x1a.wwplugin.ShutdownResponseB$Z\"github.com/wwwlkj/wwhyplugin/protob\x06proto3"
+	"\bShutdown\x12\x19.wwplugin.ShutdownRequest\x1a\x1a.wwplugin.ShutdownResponse\x12?\n" +
+	"\n" +
+	"PushConfig\x12\x17.wwplugin.ConfigRequest\x1a\x18.wwplugin.ConfigResponse\x125\n" +
+	"\x04Echo\x12\x15.wwplugin.EchoRequest\x1a\x16.wwplugin.EchoResponse\x12Y\n" +
+	"\x12ConfigureHeartbeat\x12 .wwplugin.HeartbeatConfigRequest\x1a!.wwplugin.HeartbeatConfigResponseB$Z\"github.com/wwwlkj/wwhyplugin/protob\x06proto3"
 
 var (
 	file_proto_plugin_proto_rawDescOnce sync.Once
@@ -1220,59 +1990,97 @@ func file_proto_plugin_proto_rawDescGZIP() []byte {
 	return file_proto_plugin_proto_rawDescData
 }
 
-var file_proto_plugin_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_proto_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_proto_plugin_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
 var file_proto_plugin_proto_goTypes = []any{
-	(ParameterType)(0),        // 0: wwplugin.ParameterType
-	(LogLevel)(0),             // 1: wwplugin.LogLevel
-	(*RegisterRequest)(nil),   // 2: wwplugin.RegisterRequest
-	(*RegisterResponse)(nil),  // 3: wwplugin.RegisterResponse
-	(*HeartbeatRequest)(nil),  // 4: wwplugin.HeartbeatRequest
-	(*HeartbeatResponse)(nil), // 5: wwplugin.HeartbeatResponse
-	(*CallRequest)(nil),       // 6: wwplugin.CallRequest
-	(*CallResponse)(nil),      // 7: wwplugin.CallResponse
-	(*Parameter)(nil),         // 8: wwplugin.Parameter
-	(*LogRequest)(nil),        // 9: wwplugin.LogRequest
-	(*LogResponse)(nil),       // 10: wwplugin.LogResponse
-	(*MessageRequest)(nil),    // 11: wwplugin.MessageRequest
-	(*MessageResponse)(nil),   // 12: wwplugin.MessageResponse
-	(*StatusRequest)(nil),     // 13: wwplugin.StatusRequest
-	(*StatusResponse)(nil),    // 14: wwplugin.StatusResponse
-	(*ShutdownRequest)(nil),   // 15: wwplugin.ShutdownRequest
-	(*ShutdownResponse)(nil),  // 16: wwplugin.ShutdownResponse
-	nil,                       // 17: wwplugin.CallRequest.MetadataEntry
-	nil,                       // 18: wwplugin.MessageRequest.MetadataEntry
-	nil,                       // 19: wwplugin.StatusResponse.MetricsEntry
+	(ParameterType)(0),              // 0: wwplugin.ParameterType
+	(LogLevel)(0),                   // 1: wwplugin.LogLevel
+	(ShutdownReason)(0),             // 2: wwplugin.ShutdownReason
+	(*RegisterRequest)(nil),         // 3: wwplugin.RegisterRequest
+	(*RegisterResponse)(nil),        // 4: wwplugin.RegisterResponse
+	(*HeartbeatRequest)(nil),        // 5: wwplugin.HeartbeatRequest
+	(*HeartbeatResponse)(nil),       // 6: wwplugin.HeartbeatResponse
+	(*CallRequest)(nil),             // 7: wwplugin.CallRequest
+	(*CallResponse)(nil),            // 8: wwplugin.CallResponse
+	(*Parameter)(nil),               // 9: wwplugin.Parameter
+	(*LogRequest)(nil),              // 10: wwplugin.LogRequest
+	(*LogBatchRequest)(nil),         // 11: wwplugin.LogBatchRequest
+	(*LogResponse)(nil),             // 12: wwplugin.LogResponse
+	(*MessageRequest)(nil),          // 13: wwplugin.MessageRequest
+	(*MessageResponse)(nil),         // 14: wwplugin.MessageResponse
+	(*StatusRequest)(nil),           // 15: wwplugin.StatusRequest
+	(*StatusResponse)(nil),          // 16: wwplugin.StatusResponse
+	(*ShutdownRequest)(nil),         // 17: wwplugin.ShutdownRequest
+	(*ShutdownResponse)(nil),        // 18: wwplugin.ShutdownResponse
+	(*ConfigRequest)(nil),           // 19: wwplugin.ConfigRequest
+	(*ConfigResponse)(nil),          // 20: wwplugin.ConfigResponse
+	(*CapabilitiesRequest)(nil),     // 21: wwplugin.CapabilitiesRequest
+	(*CapabilitiesResponse)(nil),    // 22: wwplugin.CapabilitiesResponse
+	(*ListFunctionsRequest)(nil),    // 23: wwplugin.ListFunctionsRequest
+	(*ListFunctionsResponse)(nil),   // 24: wwplugin.ListFunctionsResponse
+	(*EchoRequest)(nil),             // 25: wwplugin.EchoRequest
+	(*EchoResponse)(nil),            // 26: wwplugin.EchoResponse
+	(*HeartbeatConfigRequest)(nil),  // 27: wwplugin.HeartbeatConfigRequest
+	(*HeartbeatConfigResponse)(nil), // 28: wwplugin.HeartbeatConfigResponse
+	nil,                             // 29: wwplugin.RegisterRequest.FunctionTimeoutsMsEntry
+	nil,                             // 30: wwplugin.HeartbeatRequest.HealthEntry
+	nil,                             // 31: wwplugin.CallRequest.MetadataEntry
+	nil,                             // 32: wwplugin.CallResponse.MetadataEntry
+	nil,                             // 33: wwplugin.MessageRequest.MetadataEntry
+	nil,                             // 34: wwplugin.StatusResponse.MetricsEntry
+	nil,                             // 35: wwplugin.ConfigRequest.ConfigEntry
 }
 var file_proto_plugin_proto_depIdxs = []int32{
-	8,  // 0: wwplugin.CallRequest.parameters:type_name -> wwplugin.Parameter
-	17, // 1: wwplugin.CallRequest.metadata:type_name -> wwplugin.CallRequest.MetadataEntry
-	8,  // 2: wwplugin.CallResponse.result:type_name -> wwplugin.Parameter
-	0,  // 3: wwplugin.Parameter.type:type_name -> wwplugin.ParameterType
-	1,  // 4: wwplugin.LogRequest.level:type_name -> wwplugin.LogLevel
-	18, // 5: wwplugin.MessageRequest.metadata:type_name -> wwplugin.MessageRequest.MetadataEntry
-	19, // 6: wwplugin.StatusResponse.metrics:type_name -> wwplugin.StatusResponse.MetricsEntry
-	2,  // 7: wwplugin.HostService.RegisterPlugin:input_type -> wwplugin.RegisterRequest
-	4,  // 8: wwplugin.HostService.Heartbeat:input_type -> wwplugin.HeartbeatRequest
-	6,  // 9: wwplugin.HostService.CallHostFunction:input_type -> wwplugin.CallRequest
-	9,  // 10: wwplugin.HostService.ReportLog:input_type -> wwplugin.LogRequest
-	6,  // 11: wwplugin.PluginService.CallPluginFunction:input_type -> wwplugin.CallRequest
-	11, // 12: wwplugin.PluginService.ReceiveMessages:input_type -> wwplugin.MessageRequest
-	13, // 13: wwplugin.PluginService.GetPluginStatus:input_type -> wwplugin.StatusRequest
-	15, // 14: wwplugin.PluginService.Shutdown:input_type -> wwplugin.ShutdownRequest
-	3,  // 15: wwplugin.HostService.RegisterPlugin:output_type -> wwplugin.RegisterResponse
-	5,  // 16: wwplugin.HostService.Heartbeat:output_type -> wwplugin.HeartbeatResponse
-	7,  // 17: wwplugin.HostService.CallHostFunction:output_type -> wwplugin.CallResponse
-	10, // 18: wwplugin.HostService.ReportLog:output_type -> wwplugin.LogResponse
-	7,  // 19: wwplugin.PluginService.CallPluginFunction:output_type -> wwplugin.CallResponse
-	12, // 20: wwplugin.PluginService.ReceiveMessages:output_type -> wwplugin.MessageResponse
-	14, // 21: wwplugin.PluginService.GetPluginStatus:output_type -> wwplugin.StatusResponse
-	16, // 22: wwplugin.PluginService.Shutdown:output_type -> wwplugin.ShutdownResponse
-	15, // [15:23] is the sub-list for method output_type
-	7,  // [7:15] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	29, // 0: wwplugin.RegisterRequest.function_timeouts_ms:type_name -> wwplugin.RegisterRequest.FunctionTimeoutsMsEntry
+	30, // 1: wwplugin.HeartbeatRequest.health:type_name -> wwplugin.HeartbeatRequest.HealthEntry
+	9,  // 2: wwplugin.CallRequest.parameters:type_name -> wwplugin.Parameter
+	31, // 3: wwplugin.CallRequest.metadata:type_name -> wwplugin.CallRequest.MetadataEntry
+	9,  // 4: wwplugin.CallResponse.result:type_name -> wwplugin.Parameter
+	32, // 5: wwplugin.CallResponse.metadata:type_name -> wwplugin.CallResponse.MetadataEntry
+	0,  // 6: wwplugin.Parameter.type:type_name -> wwplugin.ParameterType
+	1,  // 7: wwplugin.LogRequest.level:type_name -> wwplugin.LogLevel
+	10, // 8: wwplugin.LogBatchRequest.entries:type_name -> wwplugin.LogRequest
+	33, // 9: wwplugin.MessageRequest.metadata:type_name -> wwplugin.MessageRequest.MetadataEntry
+	34, // 10: wwplugin.StatusResponse.metrics:type_name -> wwplugin.StatusResponse.MetricsEntry
+	2,  // 11: wwplugin.ShutdownRequest.reason_code:type_name -> wwplugin.ShutdownReason
+	35, // 12: wwplugin.ConfigRequest.config:type_name -> wwplugin.ConfigRequest.ConfigEntry
+	3,  // 13: wwplugin.HostService.RegisterPlugin:input_type -> wwplugin.RegisterRequest
+	5,  // 14: wwplugin.HostService.Heartbeat:input_type -> wwplugin.HeartbeatRequest
+	7,  // 15: wwplugin.HostService.CallHostFunction:input_type -> wwplugin.CallRequest
+	7,  // 16: wwplugin.HostService.CallHostFunctionStream:input_type -> wwplugin.CallRequest
+	10, // 17: wwplugin.HostService.ReportLog:input_type -> wwplugin.LogRequest
+	11, // 18: wwplugin.HostService.ReportLogs:input_type -> wwplugin.LogBatchRequest
+	21, // 19: wwplugin.HostService.UpdateCapabilities:input_type -> wwplugin.CapabilitiesRequest
+	23, // 20: wwplugin.HostService.ListHostFunctions:input_type -> wwplugin.ListFunctionsRequest
+	25, // 21: wwplugin.HostService.Echo:input_type -> wwplugin.EchoRequest
+	7,  // 22: wwplugin.PluginService.CallPluginFunction:input_type -> wwplugin.CallRequest
+	13, // 23: wwplugin.PluginService.ReceiveMessages:input_type -> wwplugin.MessageRequest
+	15, // 24: wwplugin.PluginService.GetPluginStatus:input_type -> wwplugin.StatusRequest
+	17, // 25: wwplugin.PluginService.Shutdown:input_type -> wwplugin.ShutdownRequest
+	19, // 26: wwplugin.PluginService.PushConfig:input_type -> wwplugin.ConfigRequest
+	25, // 27: wwplugin.PluginService.Echo:input_type -> wwplugin.EchoRequest
+	27, // 28: wwplugin.PluginService.ConfigureHeartbeat:input_type -> wwplugin.HeartbeatConfigRequest
+	4,  // 29: wwplugin.HostService.RegisterPlugin:output_type -> wwplugin.RegisterResponse
+	6,  // 30: wwplugin.HostService.Heartbeat:output_type -> wwplugin.HeartbeatResponse
+	8,  // 31: wwplugin.HostService.CallHostFunction:output_type -> wwplugin.CallResponse
+	8,  // 32: wwplugin.HostService.CallHostFunctionStream:output_type -> wwplugin.CallResponse
+	12, // 33: wwplugin.HostService.ReportLog:output_type -> wwplugin.LogResponse
+	12, // 34: wwplugin.HostService.ReportLogs:output_type -> wwplugin.LogResponse
+	22, // 35: wwplugin.HostService.UpdateCapabilities:output_type -> wwplugin.CapabilitiesResponse
+	24, // 36: wwplugin.HostService.ListHostFunctions:output_type -> wwplugin.ListFunctionsResponse
+	26, // 37: wwplugin.HostService.Echo:output_type -> wwplugin.EchoResponse
+	8,  // 38: wwplugin.PluginService.CallPluginFunction:output_type -> wwplugin.CallResponse
+	14, // 39: wwplugin.PluginService.ReceiveMessages:output_type -> wwplugin.MessageResponse
+	16, // 40: wwplugin.PluginService.GetPluginStatus:output_type -> wwplugin.StatusResponse
+	18, // 41: wwplugin.PluginService.Shutdown:output_type -> wwplugin.ShutdownResponse
+	20, // 42: wwplugin.PluginService.PushConfig:output_type -> wwplugin.ConfigResponse
+	26, // 43: wwplugin.PluginService.Echo:output_type -> wwplugin.EchoResponse
+	28, // 44: wwplugin.PluginService.ConfigureHeartbeat:output_type -> wwplugin.HeartbeatConfigResponse
+	29, // [29:45] is the sub-list for method output_type
+	13, // [13:29] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_proto_plugin_proto_init() }
@@ -1285,8 +2093,8 @@ func file_proto_plugin_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_plugin_proto_rawDesc), len(file_proto_plugin_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   18,
+			NumEnums:      3,
+			NumMessages:   33,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -23,8 +23,18 @@ type HostServiceClient interface {
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
 	// 插件调用主程序的函数
 	CallHostFunction(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*CallResponse, error)
+	// 插件调用主程序的流式函数，结果逐条返回
+	CallHostFunctionStream(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (HostService_CallHostFunctionStreamClient, error)
 	// 插件上报日志
 	ReportLog(ctx context.Context, in *LogRequest, opts ...grpc.CallOption) (*LogResponse, error)
+	// 插件批量上报日志
+	ReportLogs(ctx context.Context, in *LogBatchRequest, opts ...grpc.CallOption) (*LogResponse, error)
+	// 插件更新能力列表
+	UpdateCapabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
+	// 插件查询主程序提供的函数列表
+	ListHostFunctions(ctx context.Context, in *ListFunctionsRequest, opts ...grpc.CallOption) (*ListFunctionsResponse, error)
+	// 回显请求内容，用于测量往返延迟
+	Echo(ctx context.Context, in *EchoRequest, opts ...grpc.CallOption) (*EchoResponse, error)
 }
 
 type hostServiceClient struct {
@@ -62,6 +72,38 @@ func (c *hostServiceClient) CallHostFunction(ctx context.Context, in *CallReques
 	return out, nil
 }
 
+func (c *hostServiceClient) CallHostFunctionStream(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (HostService_CallHostFunctionStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &HostService_ServiceDesc.Streams[0], "/wwplugin.HostService/CallHostFunctionStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hostServiceCallHostFunctionStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type HostService_CallHostFunctionStreamClient interface {
+	Recv() (*CallResponse, error)
+	grpc.ClientStream
+}
+
+type hostServiceCallHostFunctionStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *hostServiceCallHostFunctionStreamClient) Recv() (*CallResponse, error) {
+	m := new(CallResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *hostServiceClient) ReportLog(ctx context.Context, in *LogRequest, opts ...grpc.CallOption) (*LogResponse, error) {
 	out := new(LogResponse)
 	err := c.cc.Invoke(ctx, "/wwplugin.HostService/ReportLog", in, out, opts...)
@@ -71,6 +113,42 @@ func (c *hostServiceClient) ReportLog(ctx context.Context, in *LogRequest, opts
 	return out, nil
 }
 
+func (c *hostServiceClient) ReportLogs(ctx context.Context, in *LogBatchRequest, opts ...grpc.CallOption) (*LogResponse, error) {
+	out := new(LogResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.HostService/ReportLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) UpdateCapabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
+	out := new(CapabilitiesResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.HostService/UpdateCapabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) ListHostFunctions(ctx context.Context, in *ListFunctionsRequest, opts ...grpc.CallOption) (*ListFunctionsResponse, error) {
+	out := new(ListFunctionsResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.HostService/ListHostFunctions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) Echo(ctx context.Context, in *EchoRequest, opts ...grpc.CallOption) (*EchoResponse, error) {
+	out := new(EchoResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.HostService/Echo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HostServiceServer is the server API for HostService service.
 type HostServiceServer interface {
 	// 插件注册
@@ -79,8 +157,18 @@ type HostServiceServer interface {
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
 	// 插件调用主程序的函数
 	CallHostFunction(context.Context, *CallRequest) (*CallResponse, error)
+	// 插件调用主程序的流式函数，结果逐条返回
+	CallHostFunctionStream(*CallRequest, HostService_CallHostFunctionStreamServer) error
 	// 插件上报日志
 	ReportLog(context.Context, *LogRequest) (*LogResponse, error)
+	// 插件批量上报日志
+	ReportLogs(context.Context, *LogBatchRequest) (*LogResponse, error)
+	// 插件更新能力列表
+	UpdateCapabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error)
+	// 插件查询主程序提供的函数列表
+	ListHostFunctions(context.Context, *ListFunctionsRequest) (*ListFunctionsResponse, error)
+	// 回显请求内容，用于测量往返延迟
+	Echo(context.Context, *EchoRequest) (*EchoResponse, error)
 }
 
 // UnimplementedHostServiceServer must be embedded to have forward compatible implementations.
@@ -96,9 +184,24 @@ func (UnimplementedHostServiceServer) Heartbeat(context.Context, *HeartbeatReque
 func (UnimplementedHostServiceServer) CallHostFunction(context.Context, *CallRequest) (*CallResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CallHostFunction not implemented")
 }
+func (UnimplementedHostServiceServer) CallHostFunctionStream(*CallRequest, HostService_CallHostFunctionStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CallHostFunctionStream not implemented")
+}
 func (UnimplementedHostServiceServer) ReportLog(context.Context, *LogRequest) (*LogResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReportLog not implemented")
 }
+func (UnimplementedHostServiceServer) ReportLogs(context.Context, *LogBatchRequest) (*LogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportLogs not implemented")
+}
+func (UnimplementedHostServiceServer) UpdateCapabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCapabilities not implemented")
+}
+func (UnimplementedHostServiceServer) ListHostFunctions(context.Context, *ListFunctionsRequest) (*ListFunctionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHostFunctions not implemented")
+}
+func (UnimplementedHostServiceServer) Echo(context.Context, *EchoRequest) (*EchoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Echo not implemented")
+}
 
 func RegisterHostServiceServer(s grpc.ServiceRegistrar, srv HostServiceServer) {
 	s.RegisterService(&HostService_ServiceDesc, srv)
@@ -158,6 +261,27 @@ func _HostService_CallHostFunction_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HostService_CallHostFunctionStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CallRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HostServiceServer).CallHostFunctionStream(m, &hostServiceCallHostFunctionStreamServer{stream})
+}
+
+type HostService_CallHostFunctionStreamServer interface {
+	Send(*CallResponse) error
+	grpc.ServerStream
+}
+
+type hostServiceCallHostFunctionStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *hostServiceCallHostFunctionStreamServer) Send(m *CallResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _HostService_ReportLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogRequest)
 	if err := dec(in); err != nil {
@@ -176,6 +300,78 @@ func _HostService_ReportLog_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HostService_ReportLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).ReportLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.HostService/ReportLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).ReportLogs(ctx, req.(*LogBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_UpdateCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).UpdateCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.HostService/UpdateCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).UpdateCapabilities(ctx, req.(*CapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_ListHostFunctions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFunctionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).ListHostFunctions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.HostService/ListHostFunctions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).ListHostFunctions(ctx, req.(*ListFunctionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_Echo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EchoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).Echo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.HostService/Echo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).Echo(ctx, req.(*EchoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var HostService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "wwplugin.HostService",
 	HandlerType: (*HostServiceServer)(nil),
@@ -196,8 +392,30 @@ var HostService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReportLog",
 			Handler:    _HostService_ReportLog_Handler,
 		},
+		{
+			MethodName: "ReportLogs",
+			Handler:    _HostService_ReportLogs_Handler,
+		},
+		{
+			MethodName: "UpdateCapabilities",
+			Handler:    _HostService_UpdateCapabilities_Handler,
+		},
+		{
+			MethodName: "ListHostFunctions",
+			Handler:    _HostService_ListHostFunctions_Handler,
+		},
+		{
+			MethodName: "Echo",
+			Handler:    _HostService_Echo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CallHostFunctionStream",
+			Handler:       _HostService_CallHostFunctionStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/plugin.proto",
 }
 
@@ -211,6 +429,12 @@ type PluginServiceClient interface {
 	GetPluginStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
 	// 插件关闭通知
 	Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownResponse, error)
+	// 主程序向插件推送配置
+	PushConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
+	// 回显请求内容，用于测量往返延迟和存活检测
+	Echo(ctx context.Context, in *EchoRequest, opts ...grpc.CallOption) (*EchoResponse, error)
+	// 主程序调整插件的心跳间隔
+	ConfigureHeartbeat(ctx context.Context, in *HeartbeatConfigRequest, opts ...grpc.CallOption) (*HeartbeatConfigResponse, error)
 }
 
 type pluginServiceClient struct {
@@ -282,6 +506,33 @@ func (c *pluginServiceClient) Shutdown(ctx context.Context, in *ShutdownRequest,
 	return out, nil
 }
 
+func (c *pluginServiceClient) PushConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.PluginService/PushConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) Echo(ctx context.Context, in *EchoRequest, opts ...grpc.CallOption) (*EchoResponse, error) {
+	out := new(EchoResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.PluginService/Echo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) ConfigureHeartbeat(ctx context.Context, in *HeartbeatConfigRequest, opts ...grpc.CallOption) (*HeartbeatConfigResponse, error) {
+	out := new(HeartbeatConfigResponse)
+	err := c.cc.Invoke(ctx, "/wwplugin.PluginService/ConfigureHeartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PluginServiceServer is the server API for PluginService service.
 type PluginServiceServer interface {
 	// 主程序调用插件函数
@@ -292,6 +543,12 @@ type PluginServiceServer interface {
 	GetPluginStatus(context.Context, *StatusRequest) (*StatusResponse, error)
 	// 插件关闭通知
 	Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error)
+	// 主程序向插件推送配置
+	PushConfig(context.Context, *ConfigRequest) (*ConfigResponse, error)
+	// 回显请求内容，用于测量往返延迟和存活检测
+	Echo(context.Context, *EchoRequest) (*EchoResponse, error)
+	// 主程序调整插件的心跳间隔
+	ConfigureHeartbeat(context.Context, *HeartbeatConfigRequest) (*HeartbeatConfigResponse, error)
 }
 
 // UnimplementedPluginServiceServer must be embedded to have forward compatible implementations.
@@ -310,6 +567,15 @@ func (UnimplementedPluginServiceServer) GetPluginStatus(context.Context, *Status
 func (UnimplementedPluginServiceServer) Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
 }
+func (UnimplementedPluginServiceServer) PushConfig(context.Context, *ConfigRequest) (*ConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushConfig not implemented")
+}
+func (UnimplementedPluginServiceServer) Echo(context.Context, *EchoRequest) (*EchoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Echo not implemented")
+}
+func (UnimplementedPluginServiceServer) ConfigureHeartbeat(context.Context, *HeartbeatConfigRequest) (*HeartbeatConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfigureHeartbeat not implemented")
+}
 
 func RegisterPluginServiceServer(s grpc.ServiceRegistrar, srv PluginServiceServer) {
 	s.RegisterService(&PluginService_ServiceDesc, srv)
@@ -395,6 +661,60 @@ func _PluginService_Shutdown_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PluginService_PushConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).PushConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.PluginService/PushConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).PushConfig(ctx, req.(*ConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_Echo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EchoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).Echo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.PluginService/Echo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).Echo(ctx, req.(*EchoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_ConfigureHeartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).ConfigureHeartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wwplugin.PluginService/ConfigureHeartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).ConfigureHeartbeat(ctx, req.(*HeartbeatConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var PluginService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "wwplugin.PluginService",
 	HandlerType: (*PluginServiceServer)(nil),
@@ -411,6 +731,18 @@ var PluginService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Shutdown",
 			Handler:    _PluginService_Shutdown_Handler,
 		},
+		{
+			MethodName: "PushConfig",
+			Handler:    _PluginService_PushConfig_Handler,
+		},
+		{
+			MethodName: "Echo",
+			Handler:    _PluginService_Echo_Handler,
+		},
+		{
+			MethodName: "ConfigureHeartbeat",
+			Handler:    _PluginService_ConfigureHeartbeat_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
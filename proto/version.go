@@ -0,0 +1,5 @@
+package proto
+
+// ProtoVersion 协议版本号
+// 每次修改 plugin.proto 时递增，主机与插件在注册时交换，用于发现未同步重新构建的一方
+const ProtoVersion int32 = 6
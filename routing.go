@@ -0,0 +1,80 @@
+// Package wwplugin 能力路由
+// 根据能力名称在多个提供相同能力的插件之间选择目标插件
+package wwplugin
+
+import (
+	"fmt"         // 格式化输出，用于错误信息
+	"sync/atomic" // 原子操作，用于轮询计数
+)
+
+// RouteStrategy 路由选择策略类型定义
+// candidates 为提供该能力且正在运行的插件（按注册顺序，至少一个）
+type RouteStrategy func(capability string, candidates []*PluginInfo) *PluginInfo
+
+// RouteFirst 选择第一个可用插件（默认策略）
+func RouteFirst(capability string, candidates []*PluginInfo) *PluginInfo {
+	return candidates[0]
+}
+
+// RouteLeastLoaded 选择当前进行中调用数最少的插件
+func RouteLeastLoaded(capability string, candidates []*PluginInfo) *PluginInfo {
+	selected := candidates[0]
+	for _, plugin := range candidates[1:] {
+		if plugin.ActiveCalls() < selected.ActiveCalls() {
+			selected = plugin
+		}
+	}
+	return selected
+}
+
+// NewRoundRobinStrategy 创建轮询路由策略
+// 每次调用依次选择下一个插件，计数在所有能力间共享
+func NewRoundRobinStrategy() RouteStrategy {
+	var counter uint64
+	return func(capability string, candidates []*PluginInfo) *PluginInfo {
+		n := atomic.AddUint64(&counter, 1) - 1
+		return candidates[n%uint64(len(candidates))]
+	}
+}
+
+// SetRouteStrategy 设置能力路由策略，传入nil恢复默认策略
+func (ph *PluginHost) SetRouteStrategy(strategy RouteStrategy) {
+	if strategy == nil {
+		strategy = RouteFirst
+	}
+	ph.routeMutex.Lock()
+	defer ph.routeMutex.Unlock()
+	ph.routeStrategy = strategy
+}
+
+// FindPluginsByCapability 获取提供指定能力的所有插件
+func (ph *PluginHost) FindPluginsByCapability(capability string) []*PluginInfo {
+	return ph.registry.FindByCapability(capability)
+}
+
+// CapabilityMap 获取所有已注册插件提供的能力，以及每个能力由哪些插件提供（按注册顺序）
+// 路由表随插件注册、注销和能力更新同步维护，调用时不遍历插件；结果包含未运行的插件，可结合插件状态展示
+func (ph *PluginHost) CapabilityMap() map[string][]string {
+	return ph.registry.CapabilityMap()
+}
+
+// Route 根据能力选择一个正在运行的插件
+// 调用方无需知道具体由哪个插件提供该能力
+func (ph *PluginHost) Route(capability string) (*PluginInfo, error) {
+	var candidates []*PluginInfo
+	for _, plugin := range ph.registry.FindByCapability(capability) {
+		if plugin.Status == StatusRunning {
+			candidates = append(candidates, plugin)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("没有正在运行的插件提供能力: %s", capability)
+	}
+
+	ph.routeMutex.RLock()
+	strategy := ph.routeStrategy
+	ph.routeMutex.RUnlock()
+
+	return strategy(capability, candidates), nil
+}
@@ -3,8 +3,11 @@
 package wwplugin
 
 import (
-	"log" // 日志记录，用于输出运行信息
-	"net" // 网络接口，用于IPC通信
+	"log"         // 日志记录，用于输出运行信息
+	"net"         // 网络接口，用于IPC通信
+	"sync"        // 同步原语，保证关闭操作只执行一次
+	"sync/atomic" // 原子操作，用于丢弃计数
+	"time"        // 时间处理，用于命令通道等待超时
 )
 
 // SingletonManager 单实例管理器结构体
@@ -14,15 +17,28 @@ type SingletonManager struct {
 	listener net.Listener         // IPC监听器
 	isFirst  bool                 // 是否为首个实例
 	cmdChan  chan *CommandMessage // 命令消息通道
+	dropped  uint64               // 因通道已满被丢弃的命令数 - 原子计数
+
+	// === 关闭控制 === //
+	closeOnce sync.Once     // 保证关闭操作只执行一次
+	closeErr  error         // 首次关闭监听器的结果
+	done      chan struct{} // 关闭信号 - 通知正在等待发送的命令放弃
+	sendMutex sync.RWMutex  // 发送读锁/关闭写锁 - 保证通道关闭后不再发送
+	closed    bool          // 命令通道是否已关闭
 }
 
 // NewSingletonManager 创建单实例管理器
 // appName: 应用程序名称，用于生成互斥体名称
 // 返回值：管理器实例，错误信息
 func NewSingletonManager(appName string) (*SingletonManager, error) {
-	// 创建默认配置
-	config := DefaultSingletonConfig(appName)
+	// 使用默认配置创建
+	return NewSingletonManagerWithConfig(DefaultSingletonConfig(appName))
+}
 
+// NewSingletonManagerWithConfig 使用自定义配置创建单实例管理器
+// config: 单实例配置，可设置命令通道大小和溢出处理方式
+// 返回值：管理器实例，错误信息
+func NewSingletonManagerWithConfig(config *SingletonConfig) (*SingletonManager, error) {
 	// 检查单实例状态
 	isFirst, listener, err := CheckSingleInstance(config)
 	if err != nil {
@@ -30,7 +46,11 @@ func NewSingletonManager(appName string) (*SingletonManager, error) {
 	}
 
 	// 创建命令通道
-	cmdChan := make(chan *CommandMessage, 10)
+	bufferSize := config.CommandBufferSize
+	if bufferSize <= 0 {
+		bufferSize = 10
+	}
+	cmdChan := make(chan *CommandMessage, bufferSize)
 
 	// 创建管理器实例
 	manager := &SingletonManager{
@@ -38,6 +58,7 @@ func NewSingletonManager(appName string) (*SingletonManager, error) {
 		listener: listener,
 		isFirst:  isFirst,
 		cmdChan:  cmdChan,
+		done:     make(chan struct{}),
 	}
 
 	// 如果是首个实例且有监听器，启动命令处理
@@ -56,6 +77,7 @@ func (sm *SingletonManager) IsFirstInstance() bool {
 
 // GetCommandChannel 获取命令消息通道
 // 返回值：只读的命令消息通道
+// 在不支持单实例的平台上通道保持打开但不会收到命令，直到 Close 被调用
 func (sm *SingletonManager) GetCommandChannel() <-chan *CommandMessage {
 	return sm.cmdChan
 }
@@ -69,21 +91,44 @@ func (sm *SingletonManager) GetListenerAddress() string {
 	return ""
 }
 
+// IPCPort 获取IPC监听端口
+// 返回值：实际监听的端口号，如果没有监听器则返回0
+// 外部工具可通过 SingletonSendCommand 向该端口发送命令
+func (sm *SingletonManager) IPCPort() int {
+	if sm.listener == nil {
+		return 0
+	}
+	if addr, ok := sm.listener.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// DroppedCommands 获取因命令通道已满被丢弃的命令数
+func (sm *SingletonManager) DroppedCommands() uint64 {
+	return atomic.LoadUint64(&sm.dropped)
+}
+
 // Close 关闭单实例管理器
-// 清理所有资源，包括监听器和通道
+// 清理所有资源，包括监听器和通道；可重复调用，后续调用返回首次关闭的结果
 func (sm *SingletonManager) Close() error {
-	// 清理资源
-	CleanupSingleton()
-
-	// 关闭监听器
-	if sm.listener != nil {
-		return sm.listener.Close()
-	}
+	sm.closeOnce.Do(func() {
+		// 清理资源
+		CleanupSingleton()
 
-	// 关闭命令通道
-	close(sm.cmdChan)
+		// 关闭监听器
+		if sm.listener != nil {
+			sm.closeErr = sm.listener.Close()
+		}
 
-	return nil
+		// 通知等待中的发送放弃，再关闭命令通道
+		close(sm.done)
+		sm.sendMutex.Lock()
+		sm.closed = true
+		close(sm.cmdChan)
+		sm.sendMutex.Unlock()
+	})
+	return sm.closeErr
 }
 
 // handleIPCMessages 处理IPC消息（内部方法）
@@ -111,17 +156,47 @@ func (sm *SingletonManager) handleIPCMessages() {
 			log.Printf("📨 收到来自进程 %d 的命令: %v", message.Pid, message.Args)
 
 			// 发送到命令通道
-			select {
-			case sm.cmdChan <- message:
-				// 成功发送到通道
-			default:
-				// 通道满了，丢弃消息
-				log.Printf("⚠️ 命令通道已满，丢弃消息")
-			}
+			sm.deliverCommand(message)
 		}(conn)
 	}
 }
 
+// deliverCommand 将命令发送到命令通道（内部方法）
+// 通道已满时按 CommandOverflowTimeout 等待，仍无法发送则丢弃并通知 OnCommandDropped
+func (sm *SingletonManager) deliverCommand(message *CommandMessage) {
+	// 持有读锁期间通道不会被关闭
+	sm.sendMutex.RLock()
+	defer sm.sendMutex.RUnlock()
+	if sm.closed {
+		log.Printf("⚠️ 单实例管理器已关闭，忽略来自进程 %d 的命令", message.Pid)
+		return
+	}
+
+	select {
+	case sm.cmdChan <- message:
+		return // 成功发送到通道
+	default:
+	}
+
+	if timeout := sm.config.CommandOverflowTimeout; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case sm.cmdChan <- message:
+			return
+		case <-timer.C:
+		case <-sm.done:
+		}
+	}
+
+	// 通道满了，丢弃消息
+	atomic.AddUint64(&sm.dropped, 1)
+	log.Printf("⚠️ 命令通道已满，丢弃来自进程 %d 的命令", message.Pid)
+	if sm.config.OnCommandDropped != nil {
+		sm.config.OnCommandDropped(message)
+	}
+}
+
 // EnsureSingleInstance 确保单实例运行（简化版本）
 // appName: 应用程序名称
 // 返回值：命令消息通道（仅首个实例有效），错误信息
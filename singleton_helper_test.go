@@ -0,0 +1,29 @@
+package wwplugin
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSingletonManagerCloseTwice 重复关闭不panic，命令通道在关闭后处于关闭状态
+func TestSingletonManagerCloseTwice(t *testing.T) {
+	manager, err := NewSingletonManager("wwplugin-close-twice-test")
+	if err != nil {
+		t.Fatalf("创建单实例管理器失败: %v", err)
+	}
+	commands := manager.GetCommandChannel()
+
+	first := manager.Close()
+	if second := manager.Close(); second != first {
+		t.Fatalf("第二次 Close 返回 %v，期望与首次相同的 %v", second, first)
+	}
+
+	select {
+	case _, ok := <-commands:
+		if ok {
+			t.Fatal("关闭后命令通道仍收到命令")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("关闭后命令通道未关闭")
+	}
+}
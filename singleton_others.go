@@ -2,20 +2,32 @@
 // +build !windows
 
 // Package wwplugin 单实例管理模块 - 非Windows平台
-// 在非Windows平台提供空实现，保持API兼容性
+// 在非Windows平台提供降级实现：不强制单实例，每个进程都视为首个实例，保持API兼容性
 package wwplugin
 
 import (
-	"fmt" // 格式化输出，用于错误信息
-	"net" // 网络接口，保持接口一致性
+	"fmt"  // 格式化输出，用于错误信息
+	"log"  // 日志记录，用于提示降级运行
+	"net"  // 网络接口，保持接口一致性
+	"sync" // 同步原语，保证降级提示只输出一次
+	"time" // 时间处理，保持配置结构一致
 )
 
+// degradedNotice 保证降级提示只输出一次
+var degradedNotice sync.Once
+
 // SingletonConfig 单实例配置结构体（非Windows平台占位符）
 type SingletonConfig struct {
 	MutexName  string // 互斥体名称（在非Windows平台无效）
 	IPCPort    int    // 进程间通信端口（在非Windows平台无效）
 	Timeout    int    // 通信超时时间（在非Windows平台无效）
 	RetryCount int    // 重试次数（在非Windows平台无效）
+	StateDir   string // 端口和令牌文件所在目录（在非Windows平台无效）
+
+	// 命令通道背压控制（在非Windows平台无效）
+	CommandBufferSize      int                       // 命令通道缓冲大小
+	CommandOverflowTimeout time.Duration             // 命令通道已满时的最长等待时间
+	OnCommandDropped       func(msg *CommandMessage) // 命令被丢弃时的回调
 }
 
 // CommandMessage 进程间通信消息结构体（非Windows平台占位符）
@@ -24,6 +36,7 @@ type CommandMessage struct {
 	Pid       int      `json:"pid"`       // 发送进程的进程ID
 	Timestamp int64    `json:"timestamp"` // 消息发送时间戳
 	WorkDir   string   `json:"work_dir"`  // 工作目录路径
+	Token     string   `json:"token"`     // IPC令牌
 }
 
 // DefaultSingletonConfig 返回默认的单实例配置（非Windows平台占位符）
@@ -35,15 +48,33 @@ func DefaultSingletonConfig(appName string) *SingletonConfig {
 		IPCPort:    0,       // 端口设置为0
 		Timeout:    5,       // 默认超时时间
 		RetryCount: 3,       // 默认重试次数
+
+		CommandBufferSize: 10, // 默认缓冲10条命令
 	}
 }
 
-// CheckSingleInstance 检查单实例（非Windows平台占位实现）
+// SingletonSupported 当前平台是否支持强制单实例
+// 返回值：非Windows平台返回false，CheckSingleInstance 以降级模式运行
+func SingletonSupported() bool {
+	return false
+}
+
+// CheckSingleInstance 检查单实例（非Windows平台降级实现）
 // config: 单实例配置参数
-// 返回值：始终返回true（表示首个实例），nil监听器，不支持错误
+// 返回值：始终返回true（表示首个实例），nil监听器，nil错误
+// 降级模式下不强制单实例，也不接收其他实例的命令，调用方可按首个实例的流程正常运行
 func CheckSingleInstance(config *SingletonConfig) (isFirst bool, listener net.Listener, err error) {
-	// 非Windows平台不支持单实例功能
-	return true, nil, fmt.Errorf("单实例功能仅在Windows平台支持")
+	return CheckSingleInstanceNoExit(config)
+}
+
+// CheckSingleInstanceNoExit 检查单实例，后续实例不退出进程（非Windows平台降级实现）
+// config: 单实例配置参数
+// 返回值：始终返回true（表示首个实例），nil监听器，nil错误
+func CheckSingleInstanceNoExit(config *SingletonConfig) (isFirst bool, listener net.Listener, err error) {
+	degradedNotice.Do(func() {
+		log.Printf("⚠️ 当前平台不支持单实例强制，以降级模式运行")
+	})
+	return true, nil, nil
 }
 
 // HandleIPCConnection 处理IPC连接（非Windows平台占位实现）
@@ -54,6 +85,24 @@ func HandleIPCConnection(conn net.Conn) (*CommandMessage, error) {
 	return nil, fmt.Errorf("IPC功能仅在Windows平台支持")
 }
 
+// SingletonSendCommand 向正在运行的实例发送命令（非Windows平台占位实现）
+// 返回值：不支持错误
+func SingletonSendCommand(appName string, args []string) error {
+	return fmt.Errorf("单实例功能仅在Windows平台支持")
+}
+
+// SingletonSendCommandWithConfig 按自定义配置向正在运行的实例发送命令（非Windows平台占位实现）
+// 返回值：不支持错误
+func SingletonSendCommandWithConfig(config *SingletonConfig, args []string) error {
+	return fmt.Errorf("单实例功能仅在Windows平台支持")
+}
+
+// PortFilePath 获取IPC端口文件路径（非Windows平台占位实现）
+// 返回值：空字符串
+func PortFilePath(stateDir string, mutexName string) string {
+	return ""
+}
+
 // CleanupSingleton 清理单实例资源（非Windows平台占位实现）
 // 在非Windows平台无需执行任何操作
 func CleanupSingleton() {
@@ -6,12 +6,17 @@
 package wwplugin
 
 import (
+	"crypto/rand"   // 加密随机数，用于生成IPC令牌
+	"crypto/subtle" // 常量时间比较，用于校验IPC令牌
+	"encoding/hex"  // 十六进制编码，用于令牌文本化
 	"encoding/json" // JSON编解码，用于命令参数序列化传输
 	"fmt"           // 格式化输出，用于错误信息和调试日志
 	"net"           // 网络通信，用于进程间TCP通信
 	"os"            // 操作系统接口，用于获取命令行参数和进程信息
+	"path/filepath" // 路径处理，用于构建端口和令牌文件路径
 	"strconv"       // 字符串转换，用于数字格式化
 	"strings"       // 字符串操作，用于文件名处理
+	"sync"          // 同步原语，保护全局管理器
 	"syscall"       // 系统调用，用于Windows API操作
 	"time"          // 时间处理，用于超时控制和时间戳
 	"unsafe"        // 不安全指针操作，用于Windows API参数传递
@@ -38,10 +43,26 @@ var (
 type windowsSingletonManager struct {
 	mutexHandle syscall.Handle // 互斥体句柄，必须持续持有
 	mutexName   string         // 互斥体名称
+	stateDir    string         // 端口和令牌文件所在目录
+	token       string         // IPC令牌，其他实例发送命令时必须携带
 }
 
 // 全局变量，用于保持Windows互斥体管理器
-var globalMutexManager *windowsSingletonManager
+// IPC连接处理协程与 CleanupSingleton 并发访问，读写需持有 globalMutexLock
+var (
+	globalMutexManager *windowsSingletonManager
+	globalMutexLock    sync.Mutex
+)
+
+// currentIPCToken 获取首个实例的IPC令牌，未持有互斥体或已清理时返回空字符串
+func currentIPCToken() string {
+	globalMutexLock.Lock()
+	defer globalMutexLock.Unlock()
+	if globalMutexManager == nil {
+		return ""
+	}
+	return globalMutexManager.token
+}
 
 // CommandMessage 进程间通信消息结构体
 // 用于在不同进程实例间传递命令行参数
@@ -50,6 +71,7 @@ type CommandMessage struct {
 	Pid       int      `json:"pid"`       // 发送进程的进程ID
 	Timestamp int64    `json:"timestamp"` // 消息发送时间戳
 	WorkDir   string   `json:"work_dir"`  // 工作目录路径
+	Token     string   `json:"token"`     // IPC令牌，首个实例校验后清空
 }
 
 // SingletonConfig 单实例配置结构体
@@ -59,6 +81,12 @@ type SingletonConfig struct {
 	IPCPort    int    // 进程间通信端口，0表示自动分配
 	Timeout    int    // 通信超时时间（秒）
 	RetryCount int    // 重试次数
+	StateDir   string // 端口和令牌文件所在目录，空表示系统临时目录；容器中可指向共享卷
+
+	// 命令通道背压控制（由 SingletonManager 使用）
+	CommandBufferSize      int                       // 命令通道缓冲大小
+	CommandOverflowTimeout time.Duration             // 命令通道已满时的最长等待时间，0表示立即丢弃
+	OnCommandDropped       func(msg *CommandMessage) // 命令被丢弃时的回调，可为nil
 }
 
 // DefaultSingletonConfig 返回默认的单实例配置
@@ -69,13 +97,35 @@ func DefaultSingletonConfig(appName string) *SingletonConfig {
 		IPCPort:    0,                                        // 自动分配端口
 		Timeout:    IPC_TIMEOUT,                              // 默认超时时间
 		RetryCount: 3,                                        // 默认重试次数
+
+		CommandBufferSize: 10, // 默认缓冲10条命令
 	}
 }
 
+// SingletonSupported 当前平台是否支持强制单实例
+// 返回值：Windows平台返回true
+func SingletonSupported() bool {
+	return true
+}
+
 // CheckSingleInstance 检查单实例并处理多开情况
 // config: 单实例配置参数
 // 返回值：isFirst表示是否为首个实例，listener用于接收其他实例的命令，error表示错误信息
+// 注意：后续实例将命令发送给首个实例后直接退出进程，不会返回
 func CheckSingleInstance(config *SingletonConfig) (isFirst bool, listener net.Listener, err error) {
+	isFirst, listener, err = CheckSingleInstanceNoExit(config)
+	if err == nil && !isFirst {
+		// 发送成功后退出程序
+		os.Exit(0)
+	}
+	return isFirst, listener, err
+}
+
+// CheckSingleInstanceNoExit 检查单实例，后续实例不退出进程
+// config: 单实例配置参数
+// 返回值：isFirst表示是否为首个实例，listener用于接收其他实例的命令，error表示错误信息
+// 后续实例将命令发送给首个实例后返回 isFirst=false，由调用方决定是否退出，适用于测试和嵌入场景
+func CheckSingleInstanceNoExit(config *SingletonConfig) (isFirst bool, listener net.Listener, err error) {
 	// 参数验证
 	if config == nil {
 		return false, nil, fmt.Errorf("配置参数不能为空")
@@ -91,35 +141,37 @@ func CheckSingleInstance(config *SingletonConfig) (isFirst bool, listener net.Li
 	}
 
 	if isFirst {
-		// 首个实例：保存互斥体句柄并启动IPC服务器
-		globalMutexManager = &windowsSingletonManager{
+		// 首个实例：启动IPC服务器，成功后保存互斥体句柄
+		manager := &windowsSingletonManager{
 			mutexHandle: mutexHandle,
 			mutexName:   config.MutexName,
+			stateDir:    config.StateDir,
 		}
 
-		listener, err := startIPCServer(config.IPCPort, config.MutexName)
+		listener, err := startIPCServer(config.IPCPort, manager)
 		if err != nil {
 			// 如果启动服务器失败，释放互斥体
 			releaseMutex(mutexHandle)
-			globalMutexManager = nil
 			return false, nil, fmt.Errorf("启动IPC服务器失败: %v", err)
 		}
+
+		globalMutexLock.Lock()
+		globalMutexManager = manager
+		globalMutexLock.Unlock()
 		return true, listener, nil
 	} else {
-		// 后续实例：发送命令参数到首个实例并退出
+		// 后续实例：发送命令参数到首个实例
 		// 注意：对于后续实例，createMutex返回的mutexHandle为0，不需要关闭
 		if mutexHandle != 0 {
 			// 如果有有效的句柄，则关闭它
 			procCloseHandle.Call(uintptr(mutexHandle))
 		}
 
-		err := sendCommandToFirstInstance(config)
+		err := sendCommand(config, os.Args)
 		if err != nil {
 			return false, nil, fmt.Errorf("发送命令到首个实例失败: %v", err)
 		}
-		// 发送成功后退出程序
-		os.Exit(0)
-		return false, nil, nil // 永远不会执行到这里
+		return false, nil, nil
 	}
 }
 
@@ -186,9 +238,9 @@ func releaseMutex(handle syscall.Handle) error {
 
 // startIPCServer 启动进程间通信服务器
 // port: 监听端口，0表示自动分配
-// mutexName: 互斥体名称，用于生成端口文件名
+// manager: 单实例管理器，提供端口文件位置并保存生成的IPC令牌
 // 返回值：监听器对象，错误信息
-func startIPCServer(port int, mutexName string) (net.Listener, error) {
+func startIPCServer(port int, manager *windowsSingletonManager) (net.Listener, error) {
 	// 构建监听地址
 	address := "127.0.0.1:" + strconv.Itoa(port)
 	if port == 0 {
@@ -201,10 +253,26 @@ func startIPCServer(port int, mutexName string) (net.Listener, error) {
 		return nil, fmt.Errorf("创建TCP监听器失败: %v", err)
 	}
 
+	// 生成IPC令牌并写入令牌文件，防止任意本地进程注入命令
+	// 令牌文件须先于端口文件写入：其他实例读到端口文件时令牌文件已存在。
+	// Windows上文件权限位只控制只读属性，令牌文件的访问控制沿用所在目录的ACL
+	token, err := generateToken()
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("生成IPC令牌失败: %v", err)
+	}
+	tokenFile := tokenFilePath(manager.stateDir, manager.mutexName)
+	if err := os.WriteFile(tokenFile, []byte(token), 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("写入令牌文件失败: %v", err)
+	}
+	manager.token = token
+
 	// 将实际监听端口写入临时文件供其他实例读取
 	actualPort := listener.Addr().(*net.TCPAddr).Port
-	err = writePortToFile(actualPort, mutexName)
+	err = writePortToFile(actualPort, manager.stateDir, manager.mutexName)
 	if err != nil {
+		os.Remove(tokenFile)
 		listener.Close() // 关闭监听器
 		return nil, fmt.Errorf("写入端口文件失败: %v", err)
 	}
@@ -212,24 +280,59 @@ func startIPCServer(port int, mutexName string) (net.Listener, error) {
 	return listener, nil
 }
 
-// sendCommandToFirstInstance 发送命令参数到首个实例
+// generateToken 生成随机IPC令牌
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SingletonSendCommand 向正在运行的实例发送命令
+// appName: 应用程序名称，与首个实例调用 NewSingletonManager/DefaultSingletonConfig 时使用的名称一致
+// args: 要发送的命令参数，首个实例在 CommandMessage.Args 中收到
+// 适用于脚本或外部工具向已运行实例发送命令，无需自行查找端口文件；首个实例设置了 StateDir 时使用 SingletonSendCommandWithConfig
+func SingletonSendCommand(appName string, args []string) error {
+	return sendCommand(DefaultSingletonConfig(appName), args)
+}
+
+// SingletonSendCommandWithConfig 按自定义配置向正在运行的实例发送命令
+// config: 与首个实例一致的单实例配置，按其中的 MutexName 和 StateDir 查找端口和令牌文件
+// args: 要发送的命令参数
+func SingletonSendCommandWithConfig(config *SingletonConfig, args []string) error {
+	if config == nil {
+		return fmt.Errorf("配置参数不能为空")
+	}
+	return sendCommand(config, args)
+}
+
+// sendCommand 发送命令参数到首个实例
 // config: 单实例配置参数
-func sendCommandToFirstInstance(config *SingletonConfig) error {
+// args: 要发送的命令参数
+func sendCommand(config *SingletonConfig, args []string) error {
 	// 从临时文件读取首个实例的监听端口
-	port, err := readPortFromFile(config.MutexName)
+	port, err := readPortFromFile(config.StateDir, config.MutexName)
 	if err != nil {
 		return fmt.Errorf("读取端口文件失败: %v", err)
 	}
 
+	// 读取首个实例的IPC令牌
+	token, err := os.ReadFile(tokenFilePath(config.StateDir, config.MutexName))
+	if err != nil {
+		return fmt.Errorf("读取令牌文件失败: %v", err)
+	}
+
 	// 获取当前工作目录
 	workDir, _ := os.Getwd()
 
 	// 构建命令消息
 	message := CommandMessage{
-		Args:      os.Args,           // 当前进程的命令行参数
+		Args:      args,              // 命令参数
 		Pid:       os.Getpid(),       // 当前进程ID
 		Timestamp: time.Now().Unix(), // 当前时间戳
 		WorkDir:   workDir,           // 当前工作目录
+		Token:     string(token),     // IPC令牌
 	}
 
 	// 序列化消息为JSON
@@ -310,20 +413,45 @@ func HandleIPCConnection(conn net.Conn) (*CommandMessage, error) {
 		return nil, fmt.Errorf("反序列化消息失败: %v", err)
 	}
 
+	// 校验IPC令牌，拒绝未持有令牌的进程发送的命令；管理器可能同时被清理，只读取一次令牌
+	token := currentIPCToken()
+	if token == "" || subtle.ConstantTimeCompare([]byte(message.Token), []byte(token)) != 1 {
+		return nil, fmt.Errorf("IPC令牌校验失败，拒绝来自进程 %d 的命令", message.Pid)
+	}
+	message.Token = ""
+
 	return &message, nil
 }
 
-// writePortToFile 将端口号写入临时文件
-// port: 要写入的端口号
-// mutexName: 互斥体名称，用于生成文件名
-func writePortToFile(port int, mutexName string) error {
-	// 获取临时目录
-	tempDir := os.TempDir()
+// PortFilePath 获取首个实例写入IPC端口的文件路径
+// stateDir: 与首个实例 SingletonConfig.StateDir 一致的目录，空表示系统临时目录
+// 文件命名为 wwplugin_port_<名称>.tmp，
+// <名称> 为去掉 Global\ 前缀并将 \ / : * ? < > | 替换为 _ 后的互斥体名称，
+// 如 DefaultSingletonConfig("MyApp") 对应 %TEMP%\wwplugin_port_MyApp_Mutex.tmp
+func PortFilePath(stateDir string, mutexName string) string {
+	return portFilePath(stateDir, mutexName)
+}
 
-	// 使用互斥体名称的哈希值生成唯一但固定的文件名
+// portFilePath 获取指定目录下的IPC端口文件路径
+// stateDir: 文件所在目录，空表示系统临时目录
+func portFilePath(stateDir string, mutexName string) string {
+	return ipcFilePath(stateDir, "port", mutexName)
+}
+
+// tokenFilePath 获取IPC令牌文件路径
+// 与端口文件位于同一目录，命名为 wwplugin_token_<名称>.tmp
+func tokenFilePath(stateDir string, mutexName string) string {
+	return ipcFilePath(stateDir, "token", mutexName)
+}
+
+// ipcFilePath 生成单实例IPC相关文件的路径
+// stateDir: 文件所在目录，空表示系统临时目录
+// kind: 文件类型（port/token）
+func ipcFilePath(stateDir string, kind string, mutexName string) string {
 	// 替换路径分隔符和特殊字符，确保文件名有效
 	safeName := strings.ReplaceAll(mutexName, "Global\\", "")
 	safeName = strings.ReplaceAll(safeName, "\\", "_")
+	safeName = strings.ReplaceAll(safeName, "/", "_")
 	safeName = strings.ReplaceAll(safeName, ":", "_")
 	safeName = strings.ReplaceAll(safeName, "*", "_")
 	safeName = strings.ReplaceAll(safeName, "?", "_")
@@ -331,32 +459,31 @@ func writePortToFile(port int, mutexName string) error {
 	safeName = strings.ReplaceAll(safeName, ">", "_")
 	safeName = strings.ReplaceAll(safeName, "|", "_")
 
-	// 构建端口文件路径，使用互斥体名称而不是进程ID
-	portFile := fmt.Sprintf("%s\\wwplugin_port_%s.tmp", tempDir, safeName)
+	if stateDir == "" {
+		stateDir = os.TempDir()
+	}
+
+	// 使用互斥体名称而不是进程ID，保证其他实例能找到同一文件
+	return filepath.Join(stateDir, fmt.Sprintf("wwplugin_%s_%s.tmp", kind, safeName))
+}
+
+// writePortToFile 将端口号写入端口文件
+// port: 要写入的端口号
+// stateDir: 文件所在目录，空表示系统临时目录
+// mutexName: 互斥体名称，用于生成文件名
+func writePortToFile(port int, stateDir string, mutexName string) error {
+	portFile := portFilePath(stateDir, mutexName)
 
 	// 写入端口号到文件
 	return os.WriteFile(portFile, []byte(strconv.Itoa(port)), 0644)
 }
 
-// readPortFromFile 从临时文件读取端口号
+// readPortFromFile 从端口文件读取端口号
+// stateDir: 文件所在目录，空表示系统临时目录
 // mutexName: 互斥体名称，用于定位对应的端口文件
 // 返回值：端口号，错误信息
-func readPortFromFile(mutexName string) (int, error) {
-	// 获取临时目录
-	tempDir := os.TempDir()
-
-	// 使用与writePortToFile相同的逻辑生成文件名
-	safeName := strings.ReplaceAll(mutexName, "Global\\", "")
-	safeName = strings.ReplaceAll(safeName, "\\", "_")
-	safeName = strings.ReplaceAll(safeName, ":", "_")
-	safeName = strings.ReplaceAll(safeName, "*", "_")
-	safeName = strings.ReplaceAll(safeName, "?", "_")
-	safeName = strings.ReplaceAll(safeName, "<", "_")
-	safeName = strings.ReplaceAll(safeName, ">", "_")
-	safeName = strings.ReplaceAll(safeName, "|", "_")
-
-	// 构建端口文件路径
-	portFile := fmt.Sprintf("%s\\wwplugin_port_%s.tmp", tempDir, safeName)
+func readPortFromFile(stateDir string, mutexName string) (int, error) {
+	portFile := portFilePath(stateDir, mutexName)
 
 	// 读取端口文件内容
 	data, err := os.ReadFile(portFile)
@@ -376,6 +503,9 @@ func readPortFromFile(mutexName string) (int, error) {
 // CleanupSingleton 清理单实例相关资源
 // 在程序退出时调用，清理互斥体和临时文件等资源
 func CleanupSingleton() {
+	globalMutexLock.Lock()
+	defer globalMutexLock.Unlock()
+
 	// 释放互斥体资源
 	if globalMutexManager != nil {
 		if globalMutexManager.mutexHandle != 0 {
@@ -387,7 +517,7 @@ func CleanupSingleton() {
 
 		// 清理对应的端口文件
 		if globalMutexManager.mutexName != "" {
-			cleanupPortFile(globalMutexManager.mutexName)
+			cleanupPortFile(globalMutexManager.stateDir, globalMutexManager.mutexName)
 		}
 
 		globalMutexManager = nil
@@ -395,24 +525,12 @@ func CleanupSingleton() {
 }
 
 // cleanupPortFile 清理端口文件
+// stateDir: 文件所在目录
 // mutexName: 互斥体名称
-func cleanupPortFile(mutexName string) {
-	// 获取临时目录
-	tempDir := os.TempDir()
-
-	// 使用与writePortToFile相同的逻辑生成文件名
-	safeName := strings.ReplaceAll(mutexName, "Global\\", "")
-	safeName = strings.ReplaceAll(safeName, "\\", "_")
-	safeName = strings.ReplaceAll(safeName, ":", "_")
-	safeName = strings.ReplaceAll(safeName, "*", "_")
-	safeName = strings.ReplaceAll(safeName, "?", "_")
-	safeName = strings.ReplaceAll(safeName, "<", "_")
-	safeName = strings.ReplaceAll(safeName, ">", "_")
-	safeName = strings.ReplaceAll(safeName, "|", "_")
-
-	// 构建端口文件路径
-	portFile := fmt.Sprintf("%s\\wwplugin_port_%s.tmp", tempDir, safeName)
+func cleanupPortFile(stateDir string, mutexName string) {
+	portFile := portFilePath(stateDir, mutexName)
 
-	// 删除端口文件（忽略错误）
+	// 删除端口文件和令牌文件（忽略错误）
 	os.Remove(portFile)
+	os.Remove(tokenFilePath(stateDir, mutexName))
 }
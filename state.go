@@ -0,0 +1,81 @@
+// Package wwplugin 主机状态导出
+// 将主机的生效配置和所有插件的运行状态导出为JSON，便于附加到问题报告中排查
+package wwplugin
+
+import (
+	"encoding/json" // JSON处理，用于序列化导出结果
+	"fmt"           // 格式化输出，用于错误信息
+	"sort"          // 排序，用于按插件ID稳定排列
+	"time"          // 时间处理，用于记录导出时间和运行时长
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// hostState 主机状态导出内容
+type hostState struct {
+	ExportTime    time.Time     `json:"export_time"`    // 导出时间
+	ProtoVersion  int32         `json:"proto_version"`  // 主机使用的协议版本
+	ActualPort    int           `json:"actual_port"`    // 主机实际监听端口
+	Stats         HostStats     `json:"stats"`          // 主机聚合统计
+	Config        *HostConfig   `json:"config"`         // 主机生效配置
+	HostFunctions []string      `json:"host_functions"` // 已注册的主机函数
+	Plugins       []pluginState `json:"plugins"`        // 所有插件的状态（按插件ID排序）
+}
+
+// pluginState 插件状态导出内容
+type pluginState struct {
+	ID           string        `json:"id"`            // 插件ID
+	Name         string        `json:"name"`          // 插件名称
+	Version      string        `json:"version"`       // 插件版本
+	Status       PluginStatus  `json:"status"`        // 插件状态
+	InProcess    bool          `json:"in_process"`    // 是否为进程内插件
+	Disabled     bool          `json:"disabled"`      // 是否已禁用
+	StartTime    time.Time     `json:"start_time"`    // 插件启动时间
+	Uptime       time.Duration `json:"uptime"`        // 运行时长 - 未运行时为0
+	RestartCount int           `json:"restart_count"` // 已重启次数
+	Capabilities []string      `json:"capabilities"`  // 插件能力
+	Functions    []string      `json:"functions"`     // 插件提供的函数
+	LastError    string        `json:"last_error"`    // 最近一次出错的原因
+}
+
+// ExportState 导出主机的生效配置和所有插件的运行状态（格式化的JSON）
+// 配置中的日志、回调、监听器等对象不导出；主机下发给插件的配置可能包含密钥等敏感信息，同样不导出
+func (ph *PluginHost) ExportState() ([]byte, error) {
+	state := hostState{
+		ExportTime:    time.Now(),
+		ProtoVersion:  proto.ProtoVersion,
+		ActualPort:    ph.actualPort,
+		Stats:         ph.Stats(),
+		Config:        ph.config,
+		HostFunctions: ph.ListHostFunctions(),
+		Plugins:       make([]pluginState, 0, ph.registry.Count()),
+	}
+
+	ph.registry.Walk(func(plugin *PluginInfo) bool {
+		state.Plugins = append(state.Plugins, pluginState{
+			ID:           plugin.ID,
+			Name:         plugin.Name,
+			Version:      plugin.Version,
+			Status:       plugin.Status,
+			InProcess:    plugin.InProcess,
+			Disabled:     plugin.Disabled,
+			StartTime:    plugin.StartTime,
+			Uptime:       plugin.Uptime(),
+			RestartCount: plugin.RestartCount,
+			Capabilities: append([]string(nil), plugin.Capabilities...),
+			Functions:    append([]string(nil), plugin.Functions...),
+			LastError:    plugin.LastError,
+		})
+		return true
+	})
+
+	sort.Slice(state.Plugins, func(i, j int) bool {
+		return state.Plugins[i].ID < state.Plugins[j].ID
+	})
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化主机状态失败: %v", err)
+	}
+	return data, nil
+}
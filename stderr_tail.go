@@ -0,0 +1,81 @@
+// Package wwplugin 插件标准错误输出捕获
+// 保留插件进程最近输出的若干行stderr，插件崩溃时附加到日志和事件中便于定位原因
+package wwplugin
+
+import (
+	"bytes" // 字节处理，用于按行切分输出
+	"sync"  // 同步原语，保护缓冲区
+)
+
+// maxTailLineLength 单行最大长度，超出部分截断，防止无换行的输出占满内存
+const maxTailLineLength = 4096
+
+// tailBuffer 保留最近若干行输出的环形缓冲区，实现 io.Writer
+type tailBuffer struct {
+	lines   []string   // 环形存储的完整行
+	next    int        // 下一行写入位置
+	full    bool       // 是否已写满一轮
+	partial []byte     // 尚未遇到换行符的末尾内容
+	mutex   sync.Mutex // 缓冲区互斥锁
+}
+
+// newTailBuffer 创建保留最近 size 行的缓冲区
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{lines: make([]string, size)}
+}
+
+// Write 写入输出内容，按换行符切分为行
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			tb.appendPartial(data)
+			break
+		}
+		tb.appendPartial(data[:i])
+		tb.pushLine(string(bytes.TrimRight(tb.partial, "\r")))
+		tb.partial = tb.partial[:0]
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+// Lines 获取缓冲区中的行（按输出顺序），包括尚未换行的末尾内容
+func (tb *tailBuffer) Lines() []string {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	var lines []string
+	if tb.full {
+		lines = append(lines, tb.lines[tb.next:]...)
+	}
+	lines = append(lines, tb.lines[:tb.next]...)
+	if len(tb.partial) > 0 {
+		lines = append(lines, string(tb.partial))
+	}
+	return lines
+}
+
+// appendPartial 追加到未完成的行，超出单行长度上限的部分丢弃
+func (tb *tailBuffer) appendPartial(data []byte) {
+	if room := maxTailLineLength - len(tb.partial); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		tb.partial = append(tb.partial, data...)
+	}
+}
+
+// pushLine 写入一行，缓冲区满时覆盖最旧的行
+func (tb *tailBuffer) pushLine(line string) {
+	tb.lines[tb.next] = line
+	tb.next++
+	if tb.next == len(tb.lines) {
+		tb.next = 0
+		tb.full = true
+	}
+}
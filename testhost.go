@@ -0,0 +1,137 @@
+// Package wwplugin 插件测试主机
+// 基于内存连接（bufconn）运行真实的插件主机和插件，用于插件单元测试，不启动子进程也不占用端口
+package wwplugin
+
+import (
+	"context" // 上下文控制，用于拨号
+	"fmt"     // 格式化输出，用于构造虚拟地址和错误信息
+	"net"     // 网络操作，用于连接接口
+	"sync"    // 同步原语，保护监听器映射
+	"time"    // 时间处理，用于等待插件就绪
+
+	"google.golang.org/grpc/test/bufconn" // gRPC内存连接
+)
+
+// 测试主机常量定义
+const (
+	testHostAddress      = "bufconn-host:0" // 插件连接测试主机使用的虚拟地址
+	testBufferSize       = 1024 * 1024      // 内存连接缓冲区大小
+	testPluginReadyLimit = 10 * time.Second // 等待插件连接完成的最长时间
+)
+
+// TestHost 插件测试主机
+// 内嵌真实的 PluginHost，可直接使用 RegisterHostFunction、CallPluginFunction 等方法
+type TestHost struct {
+	*PluginHost
+
+	hostListener *bufconn.Listener            // 主机服务内存监听器
+	listeners    map[string]*bufconn.Listener // 插件服务内存监听器 - 按插件虚拟地址索引
+	plugins      []*Plugin                    // 已连接的插件 - 关闭测试主机时停止
+	nextPort     int32                        // 下一个分配给插件的虚拟端口
+	mutex        sync.Mutex                   // 监听器映射互斥锁
+}
+
+// NewTestHost 创建并启动测试主机
+// 使用完毕后应调用 Close 停止主机和已连接的插件
+func NewTestHost() (*TestHost, error) {
+	th := &TestHost{
+		hostListener: bufconn.Listen(testBufferSize),
+		listeners:    make(map[string]*bufconn.Listener),
+	}
+
+	config := DefaultHostConfig()
+	config.DebugMode = false
+	config.Listener = th.hostListener
+	config.Dialer = th.dial
+
+	host, err := NewPluginHost(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := host.Start(); err != nil {
+		return nil, err
+	}
+	th.PluginHost = host
+	return th, nil
+}
+
+// ConnectPlugin 将插件连接到测试主机
+// 插件通过内存连接启动、注册，返回时主机已可调用插件函数；插件无需处理 --info
+func (th *TestHost) ConnectPlugin(plugin *Plugin) error {
+	info := th.attachPlugin(plugin)
+
+	if _, err := plugin.StartAsync(); err != nil {
+		th.registry.Unregister(info.ID)
+		return fmt.Errorf("启动插件失败: %v", err)
+	}
+
+	th.mutex.Lock()
+	th.plugins = append(th.plugins, plugin)
+	th.mutex.Unlock()
+
+	if _, err := th.waitPluginReady(plugin.ID, testPluginReadyLimit); err != nil {
+		return fmt.Errorf("等待插件就绪失败: %v", err)
+	}
+	return nil
+}
+
+// attachPlugin 为插件分配内存连接并在主机登记，插件由调用方启动
+func (th *TestHost) attachPlugin(plugin *Plugin) *PluginInfo {
+	th.mutex.Lock()
+	th.nextPort++
+	port := th.nextPort
+	listener := bufconn.Listen(testBufferSize)
+	th.listeners[fmt.Sprintf("localhost:%d", port)] = listener
+	th.mutex.Unlock()
+
+	plugin.Port = port
+	plugin.config.HostAddress = testHostAddress
+	plugin.config.Listener = listener
+	plugin.config.Dialer = th.dial
+
+	// 与 LoadPlugin 相同，先登记插件，再由插件注册时认领
+	basic := plugin.GetPluginInfo()
+	info := &PluginInfo{
+		ID:           basic.ID,
+		Name:         basic.Name,
+		Version:      basic.Version,
+		Description:  basic.Description,
+		Capabilities: basic.Capabilities,
+		Functions:    basic.Functions,
+		Status:       StatusStopped,
+
+		FunctionTimeouts: functionTimeouts(basic.FunctionTimeouts),
+	}
+	info.resetCallContext(th.ctx)
+	th.registry.Register(info)
+	th.setPluginStatus(info, StatusStarting)
+	return info
+}
+
+// Close 停止已连接的插件和测试主机
+func (th *TestHost) Close() {
+	th.mutex.Lock()
+	plugins := th.plugins
+	th.plugins = nil
+	th.mutex.Unlock()
+
+	for _, plugin := range plugins {
+		plugin.Stop()
+	}
+	th.Stop()
+}
+
+// dial 按虚拟地址连接到主机或插件的内存监听器
+func (th *TestHost) dial(ctx context.Context, address string) (net.Conn, error) {
+	if address == testHostAddress {
+		return th.hostListener.DialContext(ctx)
+	}
+
+	th.mutex.Lock()
+	listener, exists := th.listeners[address]
+	th.mutex.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("未知的测试地址: %s", address)
+	}
+	return listener.DialContext(ctx)
+}
@@ -0,0 +1,45 @@
+package wwplugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wwwlkj/wwhyplugin/proto"
+)
+
+// newTestHost 创建测试主机，测试结束时自动关闭
+func newTestHost(t *testing.T) *TestHost {
+	t.Helper()
+	th, err := NewTestHost()
+	if err != nil {
+		t.Fatalf("创建测试主机失败: %v", err)
+	}
+	t.Cleanup(th.Close)
+	return th
+}
+
+// connectTestPlugin 创建插件并连接到测试主机
+// setup 在连接前调用，用于注册函数、调整 p.config 等，不需要时传nil
+func connectTestPlugin(t *testing.T, th *TestHost, name string, setup func(p *Plugin)) *Plugin {
+	t.Helper()
+	plugin := NewPlugin(DefaultPluginConfig(name, "1.0.0", name+" 测试插件"))
+	if setup != nil {
+		setup(plugin)
+	}
+	if err := th.ConnectPlugin(plugin); err != nil {
+		t.Fatalf("连接插件 %s 失败: %v", name, err)
+	}
+	return plugin
+}
+
+// stringResult 构造字符串类型的函数返回值
+func stringResult(value string) *proto.Parameter {
+	return &proto.Parameter{Name: "result", Type: proto.ParameterType_STRING, Value: value}
+}
+
+// returnString 返回固定字符串的插件函数
+func returnString(value string) PluginFunction {
+	return func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error) {
+		return stringResult(value), nil
+	}
+}
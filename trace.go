@@ -0,0 +1,78 @@
+// Package wwplugin 调用跟踪
+// 开启 HostConfig.TraceCalls 后以调试级别记录每次插件调用的参数和结果，便于排查插件返回值异常
+package wwplugin
+
+import (
+	"fmt"     // 格式化输出，用于拼接参数描述
+	"strings" // 字符串处理，用于拼接参数列表
+	"time"    // 时间处理，用于记录调用耗时
+
+	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
+)
+
+// maxTraceValueLength 跟踪日志中单个参数值的最大长度，超出部分截断
+const maxTraceValueLength = 256
+
+// traceCallStart 记录调用开始及参数
+func (ph *PluginHost) traceCallStart(pluginID string, req *proto.CallRequest) {
+	if !ph.config.TraceCalls {
+		return
+	}
+	ph.logger.Debug("➡️ 调用插件函数",
+		"plugin_id", pluginID,
+		"function", req.FunctionName,
+		"request_id", req.RequestId,
+		"params", ph.formatTraceParams(req.Parameters))
+}
+
+// traceCallEnd 记录调用结果
+func (ph *PluginHost) traceCallEnd(pluginID string, req *proto.CallRequest, resp *proto.CallResponse, err error, elapsed time.Duration) {
+	if !ph.config.TraceCalls {
+		return
+	}
+	if err != nil {
+		ph.logger.Debug("⬅️ 插件函数调用失败",
+			"plugin_id", pluginID,
+			"function", req.FunctionName,
+			"request_id", req.RequestId,
+			"elapsed", elapsed,
+			"error", err)
+		return
+	}
+
+	var result string
+	if resp.Result != nil {
+		result = ph.formatTraceParams([]*proto.Parameter{resp.Result})
+	}
+	ph.logger.Debug("⬅️ 插件函数返回",
+		"plugin_id", pluginID,
+		"function", req.FunctionName,
+		"request_id", req.RequestId,
+		"elapsed", elapsed,
+		"success", resp.Success,
+		"error_code", resp.ErrorCode,
+		"result", result)
+}
+
+// formatTraceParams 将参数列表格式化为 "[名称:类型=值 ...]"
+// 配置了 RedactParam 时参数值由其给出，避免敏感信息明文写入日志
+func (ph *PluginHost) formatTraceParams(params []*proto.Parameter) string {
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+
+		var value string
+		if ph.config.RedactParam != nil {
+			value = ph.config.RedactParam(param)
+		} else {
+			value = param.Value
+		}
+		if len(value) > maxTraceValueLength {
+			value = value[:maxTraceValueLength] + "...(已截断)"
+		}
+		parts = append(parts, fmt.Sprintf("%s:%s=%q", param.Name, param.Type, value))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
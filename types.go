@@ -3,11 +3,14 @@
 package wwplugin
 
 import (
-	"context" // 用于上下文控制
-	"os"      // 操作系统接口
-	"os/exec" // 进程执行
-	"sync"    // 同步原语
-	"time"    // 时间处理
+	"context"       // 用于上下文控制
+	"crypto/subtle" // 常量时间比较，用于校验调用凭证
+	"net"           // 网络操作，用于自定义传输
+	"os"            // 操作系统接口
+	"os/exec"       // 进程执行
+	"sync"          // 同步原语
+	"sync/atomic"   // 原子操作，用于调用计数
+	"time"          // 时间处理
 
 	"github.com/wwwlkj/wwhyplugin/proto" // gRPC协议定义
 	"google.golang.org/grpc"             // gRPC框架
@@ -25,6 +28,7 @@ const (
 	StatusStopping PluginStatus = "stopping" // 插件正在停止中 - 过渡状态
 	StatusError    PluginStatus = "error"    // 插件出现错误 - 需要干预
 	StatusCrashed  PluginStatus = "crashed"  // 插件崩溃 - 可能需要重启
+	StatusDisabled PluginStatus = "disabled" // 插件已禁用 - 进程和连接保持，但不接收调用
 )
 
 // PluginInfo 插件信息结构体
@@ -35,11 +39,17 @@ type PluginInfo struct {
 	Name           string   `json:"name"`            // 插件名称 - 用户友好的显示名称
 	Version        string   `json:"version"`         // 插件版本号 - 遵循语义化版本规范
 	Description    string   `json:"description"`     // 插件功能描述 - 详细说明插件作用
-	Port           int32    `json:"port"`            // 插件gRPC服务监听端口 - 用于主机连接
+	Port           int32    `json:"port"`            // 插件gRPC服务监听端口 - 用于主机连接（注册后有效）
+	Address        string   `json:"address"`         // 插件gRPC服务地址 - 主机可直接连接的地址（注册后有效）
 	Capabilities   []string `json:"capabilities"`    // 插件能力列表 - 描述插件提供的功能
 	Functions      []string `json:"functions"`       // 插件提供的函数列表 - 可调用的函数名
 	ExecutablePath string   `json:"executable_path"` // 插件可执行文件路径 - 用于启动进程
 
+	FunctionTimeouts map[string]time.Duration `json:"function_timeouts"` // 函数级调用超时 - 插件声明，未声明的函数使用 HostConfig.CallTimeout
+
+	InProcess bool `json:"in_process"` // 是否为进程内插件 - 通过 RegisterInProcessPlugin 注册，没有子进程
+	Adopted   bool `json:"adopted"`    // 是否为主机重启后接管的插件 - 不是本主机的子进程，停止时只能请求其退出，不能启动、重启或升级
+
 	// === 运行时信息 === //
 	Process       *os.Process               `json:"-"`              // 插件进程对象 - 用于进程控制
 	Command       *exec.Cmd                 `json:"-"`              // 执行命令对象 - 保存启动参数
@@ -48,11 +58,191 @@ type PluginInfo struct {
 	Status        PluginStatus              `json:"status"`         // 当前插件运行状态 - 实时状态信息
 	StartTime     time.Time                 `json:"start_time"`     // 插件启动时间 - 用于计算运行时长
 	LastHeartbeat time.Time                 `json:"last_heartbeat"` // 最后一次心跳时间 - 用于健康检查
+	HealthStatus  string                    `json:"health_status"`  // 插件心跳上报的状态 - 如 running、degraded
+	HealthDetail  map[string]string         `json:"health_detail"`  // 插件心跳上报的健康详情
+	exited        chan struct{}             // 进程退出通知 - 监控协程等待到进程结束后关闭
+	stderr        *tailBuffer               // 进程stderr最近输出 - 崩溃诊断用
+
+	LastError     string    `json:"last_error"`      // 最近一次出错的原因 - 进入错误或崩溃状态时记录，如连接失败、进程退出码
+	LastErrorTime time.Time `json:"last_error_time"` // 最近一次出错的时间
 
 	// === 配置参数 === //
 	AutoRestart  bool `json:"auto_restart"`  // 是否在插件崩溃时自动重启 - 容错配置
 	MaxRestarts  int  `json:"max_restarts"`  // 最大重启次数 - 防止无限重启
 	RestartCount int  `json:"restart_count"` // 当前已重启次数计数器 - 跟踪重启情况
+
+	Disabled bool `json:"disabled"` // 是否已禁用 - 由 DisablePlugin 设置，插件重新连接后仍保持禁用
+
+	ShutdownPriority int `json:"shutdown_priority"` // 关闭优先级 - 数值越小越先停止、越晚启动（如日志插件应设置较大值）
+
+	PluginConfigData map[string]string `json:"plugin_config_data"` // 主机下发的插件配置 - 每次连接插件后通过 PushConfig 推送
+
+	// === 负载统计 === //
+	activeCalls int64 // 正在进行中的调用数 - 用于最少负载路由
+	connecting  int32 // 主机是否正在回连插件 - 防止重复发起连接
+
+	// === 调用控制 === //
+	callCtx    context.Context    // 调用上下文 - 插件停止时取消，使进行中的调用立即结束
+	callCancel context.CancelFunc // 调用上下文取消函数
+	callMutex  sync.Mutex         // 调用上下文互斥锁 - 同时保护调用凭证
+	callToken  string             // 插件间调用凭证 - 每次注册时由主机签发，插件发起插件间调用时须携带
+
+	connMutex sync.RWMutex // gRPC连接读写锁 - 保护 Client 和 Connection，插件停止时二者会被清空
+}
+
+// StderrTail 获取插件当前（或最近一次）进程最后输出的stderr行
+// 未启用捕获（HostConfig.StderrTailLines 为0）或进程尚未启动时返回nil
+func (pi *PluginInfo) StderrTail() []string {
+	if pi.stderr == nil {
+		return nil
+	}
+	return pi.stderr.Lines()
+}
+
+// Uptime 获取插件当前进程的运行时长
+// 从最近一次启动（或重启、接管）开始计算，插件未运行时返回0
+func (pi *PluginInfo) Uptime() time.Duration {
+	if pi.Status != StatusRunning || pi.StartTime.IsZero() {
+		return 0
+	}
+	return time.Since(pi.StartTime)
+}
+
+// ActiveCalls 获取插件当前正在进行中的调用数
+func (pi *PluginInfo) ActiveCalls() int64 {
+	return atomic.LoadInt64(&pi.activeCalls)
+}
+
+// resetCallContext 为新启动的插件进程创建调用上下文
+func (pi *PluginInfo) resetCallContext(parent context.Context) {
+	pi.callMutex.Lock()
+	defer pi.callMutex.Unlock()
+	if pi.callCancel != nil {
+		pi.callCancel()
+	}
+	pi.callCtx, pi.callCancel = context.WithCancel(parent)
+}
+
+// cancelCalls 取消插件所有进行中的调用
+func (pi *PluginInfo) cancelCalls() {
+	pi.callMutex.Lock()
+	defer pi.callMutex.Unlock()
+	if pi.callCancel != nil {
+		pi.callCancel()
+	}
+}
+
+// callContext 获取插件调用上下文，所有对插件的调用都应从它派生
+func (pi *PluginInfo) callContext() context.Context {
+	pi.callMutex.Lock()
+	defer pi.callMutex.Unlock()
+	if pi.callCtx == nil {
+		return context.Background()
+	}
+	return pi.callCtx
+}
+
+// setCallToken 设置主机签发的插件间调用凭证
+func (pi *PluginInfo) setCallToken(token string) {
+	pi.callMutex.Lock()
+	defer pi.callMutex.Unlock()
+	pi.callToken = token
+}
+
+// verifyCallToken 校验插件间调用携带的凭证，插件未签发凭证时一律不通过
+func (pi *PluginInfo) verifyCallToken(token string) bool {
+	pi.callMutex.Lock()
+	defer pi.callMutex.Unlock()
+	return pi.callToken != "" && subtle.ConstantTimeCompare([]byte(pi.callToken), []byte(token)) == 1
+}
+
+// client 获取插件gRPC客户端的快照，未连接或已停止时返回nil
+// 调用插件前应先取快照再判空，避免停止流程在检查与调用之间清空客户端
+func (pi *PluginInfo) client() proto.PluginServiceClient {
+	pi.connMutex.RLock()
+	defer pi.connMutex.RUnlock()
+	return pi.Client
+}
+
+// connection 获取插件gRPC连接的快照，未连接或已停止时返回nil
+func (pi *PluginInfo) connection() *grpc.ClientConn {
+	pi.connMutex.RLock()
+	defer pi.connMutex.RUnlock()
+	return pi.Connection
+}
+
+// setConnection 设置插件的gRPC连接和客户端
+func (pi *PluginInfo) setConnection(conn *grpc.ClientConn, client proto.PluginServiceClient) {
+	pi.connMutex.Lock()
+	defer pi.connMutex.Unlock()
+	pi.Connection = conn
+	pi.Client = client
+}
+
+// closeConnection 关闭并清空插件的gRPC连接和客户端
+func (pi *PluginInfo) closeConnection() {
+	pi.connMutex.Lock()
+	conn := pi.Connection
+	pi.Connection = nil
+	pi.Client = nil
+	pi.connMutex.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// CallOptions 插件函数调用选项
+type CallOptions struct {
+	Metadata     map[string]string // 自定义元数据 - 与框架元数据合并，键冲突时以框架元数据为准
+	WaitForReady time.Duration     // 插件未运行时等待其就绪的最长时间 - 0表示立即失败
+
+	RequireFunction bool // 调用前按插件函数列表检查 - 函数不在列表中时直接返回 ErrFunctionNotFound，不发起调用
+}
+
+// RestartPolicy 插件崩溃后的自动重启策略
+type RestartPolicy struct {
+	AutoRestart bool // 是否在插件崩溃时自动重启
+	MaxRestarts int  // 最大重启次数 - 必须大于等于0
+}
+
+// LoadOptions 插件加载选项
+type LoadOptions struct {
+	RestartPolicy *RestartPolicy // 重启策略 - 为nil时使用主机的 AutoRestartPlugin 配置，最多重启3次
+}
+
+// PluginHealth 插件健康状态快照
+// 值类型副本，不随插件运行状态变化，适合仪表盘等场景安全读取
+type PluginHealth struct {
+	ID            string        `json:"id"`             // 插件ID
+	Name          string        `json:"name"`           // 插件名称
+	Status        PluginStatus  `json:"status"`         // 插件状态
+	StartTime     time.Time     `json:"start_time"`     // 插件启动时间
+	Uptime        time.Duration `json:"uptime"`         // 运行时长 - 未运行时为0
+	RestartCount  int           `json:"restart_count"`  // 已重启次数
+	LastHeartbeat time.Time     `json:"last_heartbeat"` // 最后一次心跳时间
+	ActiveCalls   int64         `json:"active_calls"`   // 正在进行中的调用数
+
+	HealthStatus string            `json:"health_status"` // 插件心跳上报的状态
+	HealthDetail map[string]string `json:"health_detail"` // 插件心跳上报的健康详情（副本）
+	ConnState    string            `json:"conn_state"`    // 主机到插件的gRPC连接状态，如 READY、TRANSIENT_FAILURE - 未建立连接时为空
+
+	LastError     string    `json:"last_error"`      // 最近一次出错的原因 - 从未出错时为空
+	LastErrorTime time.Time `json:"last_error_time"` // 最近一次出错的时间
+}
+
+// HostStats 主机聚合统计信息
+type HostStats struct {
+	StartTime      time.Time     `json:"start_time"`      // 主机启动时间
+	Uptime         time.Duration `json:"uptime"`          // 主机运行时长
+	TotalPlugins   int           `json:"total_plugins"`   // 插件总数
+	RunningPlugins int           `json:"running_plugins"` // 运行中的插件数
+	CrashedPlugins int           `json:"crashed_plugins"` // 已崩溃的插件数
+	StoppedPlugins int           `json:"stopped_plugins"` // 已停止的插件数
+	CallsServed    uint64        `json:"calls_served"`    // 主机处理的插件调用总数（含插件间转发）
+	PluginPanics   uint64        `json:"plugin_panics"`   // 插件上报的函数panic总数
+
+	DisabledPlugins int `json:"disabled_plugins"` // 已禁用的插件数
 }
 
 // PluginBasicInfo 插件基础信息结构（用于信息查询）
@@ -65,6 +255,8 @@ type PluginBasicInfo struct {
 	Logo         string   `json:"logo,omitempty"` // 插件Logo - Base64编码的图片数据或图片路径
 	Capabilities []string `json:"capabilities"`   // 插件能力 - 功能特性列表
 	Functions    []string `json:"functions"`      // 插件函数列表 - 可调用的函数名
+
+	FunctionTimeouts map[string]int64 `json:"function_timeouts_ms,omitempty"` // 函数级调用超时（毫秒）
 }
 
 // HostConfig 主程序配置结构体
@@ -74,16 +266,65 @@ type HostConfig struct {
 	Port      int   `json:"port"`       // gRPC服务端口（0表示自动分配）
 	PortRange []int `json:"port_range"` // 端口范围 [start, end] - 自动分配时的范围
 
+	DiscoveryFile string `json:"discovery_file"` // 主机地址发现文件 - 启动后写入实际监听地址并传给所启动的插件，供插件重连时读取（为空表示不使用）
+
 	// === 日志配置 === //
 	DebugMode bool   `json:"debug_mode"` // 是否开启调试模式 - 输出详细日志
 	LogLevel  string `json:"log_level"`  // 日志级别 - debug/info/warn/error
 	LogDir    string `json:"log_dir"`    // 日志目录 - 日志文件存储位置
+	Logger    Logger `json:"-"`          // 日志接口 - 为nil时使用基于标准库log的默认实现
+
+	// === 调试配置 === //
+	EnableReflection bool                          `json:"enable_reflection"` // 启用gRPC服务反射 - 便于使用grpcurl调试，生产环境应保持关闭
+	TraceCalls       bool                          `json:"trace_calls"`       // 调用跟踪 - 以调试级别记录每次插件调用的参数和结果
+	RedactParam      func(*proto.Parameter) string `json:"-"`                 // 参数脱敏 - 返回写入跟踪日志的参数值，为nil时记录原值
 
 	// === 健康监控 === //
 	HeartbeatInterval     time.Duration `json:"heartbeat_interval"`      // 心跳间隔 - 检查插件健康的时间间隔
 	MaxHeartbeatMiss      int           `json:"max_heartbeat_miss"`      // 最大心跳丢失次数 - 超过后认为插件崩溃
 	AutoRestartPlugin     bool          `json:"auto_restart_plugin"`     // 是否自动重启崩溃的插件
 	EnablePluginReconnect bool          `json:"enable_plugin_reconnect"` // 是否允许插件断线重连
+	ConnectRetries        int           `json:"connect_retries"`         // 主机回连插件的重试次数 - 全部失败后等待插件下次心跳再尝试
+	ConnectRetryInterval  time.Duration `json:"connect_retry_interval"`  // 主机回连插件的重试间隔
+
+	// === 诊断配置 === //
+	StderrTailLines int `json:"stderr_tail_lines"` // 保留插件stderr最近输出的行数 - 插件崩溃时附加到日志和事件（0表示不捕获）
+
+	// === 调用配置 === //
+	CallTimeout        time.Duration `json:"call_timeout"`         // 插件函数调用默认超时 - 插件未声明函数级超时时使用
+	InterPluginTimeout time.Duration `json:"inter_plugin_timeout"` // 插件间调用转发超时 - 不超过调用方剩余的截止时间（0表示同直接调用）
+
+	MaxParamBytes int `json:"max_param_bytes"` // 参数大小上限（字节）- 主机收发的参数和返回值编码后超过时调用以 PARAM_TOO_LARGE 失败（0表示不限制）
+
+	DefaultDenyInterPlugin bool `json:"default_deny_inter_plugin"` // 默认禁止插件间调用 - 仅允许通过 SetInterPluginPolicy 放行的调用（否则未设置策略的插件可调用任意插件）；调用方须携带注册时签发的凭证，未登记的插件ID始终被拒绝
+
+	// === 插件加载 === //
+	PreferManifest bool `json:"prefer_manifest"` // 优先读取插件清单 - 从可执行文件旁的 <插件名>.json 读取插件信息，不存在时才执行 --info
+
+	// === 主机函数 === //
+	DisableDefaultFunctions bool `json:"disable_default_functions"` // 不注册内置主机函数（GetSystemTime/GetSystemInfo/GetPluginList）- 用于收紧插件可调用的函数范围
+
+	// === 消息配置 === //
+	BroadcastConcurrency int `json:"broadcast_concurrency"` // 广播并发数 - 同时向多少个插件发送消息
+
+	// === 注册校验 === //
+	StrictRequiredFunctions bool `json:"strict_required_functions"` // 严格依赖校验 - 插件依赖的主机函数缺失时拒绝注册（否则仅警告）
+	StrictProtoVersion      bool `json:"strict_proto_version"`      // 严格协议校验 - 插件的协议版本与主机不一致时拒绝注册（否则仅警告）
+
+	// === 关闭控制 === //
+	GracefulStopTimeout time.Duration `json:"graceful_stop_timeout"` // gRPC优雅关闭超时 - 超时后强制关闭服务器
+	StopGracePeriod     time.Duration `json:"stop_grace_period"`     // 插件退出宽限期 - 请求插件优雅退出后等待的时间，超时强制终止（0表示直接终止）
+	MaxStopGracePeriod  time.Duration `json:"max_stop_grace_period"` // 插件退出宽限期上限 - 插件通过关闭处理器请求更长宽限时的最大值
+
+	// === 故障处理 === //
+	OnServeError ServeErrorHandler `json:"-"` // gRPC服务意外退出回调 - 决定主机重新监听还是关闭（为nil时关闭）
+
+	// === 传输配置 === //
+	Listener net.Listener                                                `json:"-"` // 主机服务监听器 - 为nil时按端口配置监听TCP；设置后忽略 Port/PortRange
+	Dialer   func(ctx context.Context, address string) (net.Conn, error) `json:"-"` // 连接插件的拨号函数 - 为nil时使用TCP
+
+	ServerOptions []grpc.ServerOption `json:"-"` // 主机gRPC服务器的附加选项 - 如拦截器、统计处理器
+	DialOptions   []grpc.DialOption   `json:"-"` // 连接插件的附加拨号选项 - 追加在框架默认选项之后，可覆盖默认的传输凭证
 }
 
 // PluginConfig 插件配置结构体
@@ -96,14 +337,58 @@ type PluginConfig struct {
 	Logo         string   `json:"logo,omitempty"` // 插件Logo - Base64编码的图片数据或图片路径
 	Capabilities []string `json:"capabilities"`   // 插件能力列表 - 描述插件功能特性
 
+	IDGenerator func(*PluginConfig) string `json:"-"` // 插件ID生成器 - 为nil时使用 DefaultIDGenerator；由主机启动时使用主机下发的ID
+
 	// === 网络配置 === //
 	HostAddress string `json:"host_address"` // 主程序地址 - 插件连接的主机地址
+	PortRange   []int  `json:"port_range"`   // 插件服务端口范围 [start, end] - 依次尝试范围内的端口，便于防火墙只放行固定范围（为空时使用随机端口）
+
+	HostDiscoveryFile string `json:"host_discovery_file"` // 主机地址发现文件 - 重连主机前读取最新地址（为空时使用主机通过环境变量传递的路径）
+
+	// === 日志配置 === //
+	Logger           Logger        `json:"-"`                  // 日志接口 - 为nil时使用基于标准库log的默认实现
+	LogFlushInterval time.Duration `json:"log_flush_interval"` // 日志上报间隔 - Plugin.Log 缓冲的日志定期批量发送给主机
+	LogBatchSize     int           `json:"log_batch_size"`     // 日志批量大小 - 缓冲达到该条数时立即发送
+	LogBufferSize    int           `json:"log_buffer_size"`    // 日志缓冲上限 - 超出后丢弃新日志并计数，防止淹没主机
+
+	// === 注册配置 === //
+	RegisterTimeout       time.Duration `json:"register_timeout"`        // 注册超时 - 单次注册请求等待主机响应的时间
+	RegisterRetries       int           `json:"register_retries"`        // 注册重试次数 - 主机尚未就绪时的额外尝试次数
+	RegisterRetryInterval time.Duration `json:"register_retry_interval"` // 注册重试初始间隔 - 每次失败后翻倍
+
+	// === 依赖声明 === //
+	RequiredHostFunctions []string `json:"required_host_functions"` // 依赖的主机函数 - 注册时由主机校验是否存在
+
+	// === 函数注册 === //
+	StrictFunctionRegistration bool `json:"strict_function_registration"` // 严格注册模式 - 重复注册同名函数时直接panic
+
+	// === 调试配置 === //
+	EnableReflection bool `json:"enable_reflection"` // 启用gRPC服务反射 - 便于使用grpcurl调试，生产环境应保持关闭
 
 	// === 健康监控 === //
-	HeartbeatInterval     time.Duration `json:"heartbeat_interval"`       // 心跳间隔 - 发送心跳的时间间隔
-	ReconnectInterval     time.Duration `json:"reconnect_interval"`       // 重连间隔 - 连接断开后的重连等待时间
-	MaxReconnectTries     int           `json:"max_reconnect_tries"`      // 最大重连次数（0表示无限重连）
-	CloseOnHostDisconnect bool          `json:"close_on_host_disconnect"` // 主机断开连接后是否关闭插件
+	HeartbeatInterval       time.Duration `json:"heartbeat_interval"`        // 心跳间隔 - 发送心跳的时间间隔
+	HeartbeatTimeout        time.Duration `json:"heartbeat_timeout"`         // 心跳RPC超时 - 单次心跳/连接探测等待主机响应的时间
+	ReconnectInterval       time.Duration `json:"reconnect_interval"`        // 重连间隔 - 连接断开后的重连等待时间
+	MaxReconnectTries       int           `json:"max_reconnect_tries"`       // 最大重连次数（0表示无限重连）
+	CloseOnHostDisconnect   bool          `json:"close_on_host_disconnect"`  // 主机断开连接后是否关闭插件（无限重连模式下检测到持续断开即关闭，不会重连）- 默认开启，需要断线重连或通过发现文件跟随主机重启时须设为false
+	ConnectionCheckInterval time.Duration `json:"connection_check_interval"` // 连接检查间隔 - 连接监控器探测主机的时间间隔
+	DisconnectThreshold     time.Duration `json:"disconnect_threshold"`      // 断开判定阈值 - 连续探测失败超过该时长视为主机断开
+	ReconnectJitter         float64       `json:"reconnect_jitter"`          // 重连抖动比例（如0.2表示±20%）- 避免多个插件同时重连
+	MaxReconnectInterval    time.Duration `json:"max_reconnect_interval"`    // 最大重连间隔 - 大于0时启用指数退避，间隔翻倍直至该上限
+
+	// === 消息处理 === //
+	MessageWorkers   int `json:"message_workers"`    // 消息处理协程数 - 大于0时消息交由工作池异步处理，慢处理器不阻塞消息流（0表示在消息流中依次同步处理）
+	MessageQueueSize int `json:"message_queue_size"` // 消息队列深度 - 队列满时暂停接收消息形成背压（0表示与协程数相同）
+
+	// === 关闭控制 === //
+	GracefulStopTimeout time.Duration `json:"graceful_stop_timeout"` // gRPC优雅关闭超时 - 超时后强制关闭服务器
+
+	// === 传输配置 === //
+	Listener net.Listener                                                `json:"-"` // 插件服务监听器 - 为nil时按 PortRange 监听TCP；设置后忽略 PortRange，非TCP监听器需预先设置 Plugin.Port
+	Dialer   func(ctx context.Context, address string) (net.Conn, error) `json:"-"` // 连接主机（及自检）的拨号函数 - 为nil时使用TCP
+
+	ServerOptions []grpc.ServerOption `json:"-"` // 插件gRPC服务器的附加选项 - 如拦截器、统计处理器
+	DialOptions   []grpc.DialOption   `json:"-"` // 连接主机（及自检）的附加拨号选项 - 追加在框架默认选项之后，可覆盖默认的传输凭证
 }
 
 // PluginFunction 插件函数类型定义
@@ -112,9 +397,67 @@ type PluginFunction func(ctx context.Context, params []*proto.Parameter) (*proto
 // HostFunction 主程序函数类型定义
 type HostFunction func(ctx context.Context, params []*proto.Parameter) (*proto.Parameter, error)
 
+// InProcessHandler 进程内插件处理函数类型定义
+// functionName: 被调用的函数名；请求ID和元数据可通过 RequestIDFromContext 等函数从 ctx 获取
+type InProcessHandler func(ctx context.Context, functionName string, params []*proto.Parameter) (*proto.Parameter, error)
+
+// StreamHostFunction 流式主程序函数类型定义
+// send: 向调用方发送一条结果，返回错误时（如调用方已取消）应停止发送并返回
+// 返回非nil错误时，调用方在收到已发送的结果后收到该错误
+type StreamHostFunction func(ctx context.Context, params []*proto.Parameter, send func(*proto.Parameter) error) error
+
+// StreamItem 流式调用的一条结果
+type StreamItem struct {
+	Value *proto.Parameter // 结果值
+	Err   error            // 调用错误 - 非nil时为最后一条
+}
+
+// DefaultHostName 插件注册所在主机的名称，用于 CallHostFunctionOn
+const DefaultHostName = "default"
+
 // MessageHandler 消息处理器类型定义
 type MessageHandler func(msg *proto.MessageRequest)
 
+// 插件心跳上报的常用健康状态
+const (
+	HealthRunning  = "running"  // 插件正常运行
+	HealthDegraded = "degraded" // 插件存活但功能受限，如依赖服务不可用
+)
+
+// ShutdownHandler 插件关闭处理器类型定义
+// reason: 主机给出的关闭原因，原因代码可通过 Plugin.ShutdownReason 获取
+// 返回值：需要的退出宽限时间（秒，0表示默认），非nil错误表示拒绝关闭
+type ShutdownHandler func(reason string) (graceSeconds int, err error)
+
+// ConfigHandler 插件配置处理器类型定义
+// config: 主机推送的配置项
+type ConfigHandler func(config map[string]string)
+
+// ServeErrorAction 主机gRPC服务意外退出后的处理方式
+type ServeErrorAction int
+
+// gRPC服务意外退出的处理方式常量定义
+const (
+	ServeErrorShutdown ServeErrorAction = iota // 通知 PluginHost.Wait 返回并关闭主机（默认）
+	ServeErrorRebind                           // 按端口配置重新监听并继续服务，使用自定义监听器时无法重新监听，改为关闭
+	ServeErrorIgnore                           // 不做处理，由回调自行恢复或关闭主机
+)
+
+// ServeErrorHandler 主机gRPC服务意外退出的回调类型定义
+// 返回值：主机随后的处理方式
+type ServeErrorHandler func(err error) ServeErrorAction
+
+// ReadyHandler 插件就绪回调类型定义
+// 插件成功注册到主机后调用，返回错误时插件启动失败
+type ReadyHandler func() error
+
+// ConnectionHandler 插件与主机连接状态变化的回调类型定义
+type ConnectionHandler func()
+
+// HealthFunc 插件健康状态回调类型定义
+// 返回值：状态（如 running、degraded），健康详情（如依赖服务的连接状态）
+type HealthFunc func() (status string, detail map[string]string)
+
 // LogLevel 日志级别
 type LogLevel int
 
@@ -156,54 +499,97 @@ func DefaultHostConfig() *HostConfig {
 		PortRange:             []int{50051, 50100},
 		DebugMode:             true,
 		LogLevel:              "info",
-		LogDir:                "./logs",
+		LogDir:                defaultLogDir(),
 		HeartbeatInterval:     10 * time.Second,
 		MaxHeartbeatMiss:      3,
 		AutoRestartPlugin:     true,
 		EnablePluginReconnect: true, // 默认允许插件断线重连
+		ConnectRetries:        3,
+		ConnectRetryInterval:  2 * time.Second,
+		CallTimeout:           30 * time.Second,
+		StderrTailLines:       50,
+		BroadcastConcurrency:  8,
+		GracefulStopTimeout:   10 * time.Second,
+		StopGracePeriod:       5 * time.Second,
+		MaxStopGracePeriod:    60 * time.Second,
 	}
 }
 
 // DefaultPluginConfig 返回默认的插件配置
 func DefaultPluginConfig(name, version, description string) *PluginConfig {
 	return &PluginConfig{
-		Name:                  name,
-		Version:               version,
-		Description:           description,
-		Logo:                  "", // 默认为空Logo
-		Capabilities:          []string{},
-		HostAddress:           "localhost:50051",
-		HeartbeatInterval:     10 * time.Second,
-		ReconnectInterval:     5 * time.Second,
-		MaxReconnectTries:     0,    // 无限重连
-		CloseOnHostDisconnect: true, // 默认主机断开连接后关闭插件
+		Name:                    name,
+		Version:                 version,
+		Description:             description,
+		Logo:                    "", // 默认为空Logo
+		Capabilities:            []string{},
+		HostAddress:             "localhost:50051",
+		LogFlushInterval:        time.Second,
+		LogBatchSize:            100,
+		LogBufferSize:           1000,
+		RegisterTimeout:         10 * time.Second,
+		RegisterRetries:         5,
+		RegisterRetryInterval:   1 * time.Second,
+		HeartbeatInterval:       10 * time.Second,
+		HeartbeatTimeout:        5 * time.Second,
+		ReconnectInterval:       5 * time.Second,
+		MaxReconnectTries:       0,    // 无限重连
+		CloseOnHostDisconnect:   true, // 默认主机断开连接后关闭插件
+		ConnectionCheckInterval: 15 * time.Second,
+		DisconnectThreshold:     30 * time.Second,
+		ReconnectJitter:         0.2, // 默认±20%抖动
+		MaxReconnectInterval:    0,   // 默认不启用指数退避
+		GracefulStopTimeout:     10 * time.Second,
 	}
 }
 
 // PluginRegistry 插件注册表
+// 同时维护能力到插件ID的路由表，随注册/注销同步更新
 type PluginRegistry struct {
-	plugins map[string]*PluginInfo
-	mutex   sync.RWMutex
+	plugins      map[string]*PluginInfo
+	capabilities map[string][]string // 能力 -> 提供该能力的插件ID列表（按注册顺序）
+	indexedCaps  map[string][]string // 插件ID -> 已写入路由表的能力快照
+	names        map[string][]string // 插件名称 -> 同名插件ID列表（按注册顺序）
+	indexedNames map[string]string   // 插件ID -> 已写入名称索引的名称快照
+	mutex        sync.RWMutex
 }
 
 // NewPluginRegistry 创建新的插件注册表
 func NewPluginRegistry() *PluginRegistry {
 	return &PluginRegistry{
-		plugins: make(map[string]*PluginInfo),
+		plugins:      make(map[string]*PluginInfo),
+		capabilities: make(map[string][]string),
+		indexedCaps:  make(map[string][]string),
+		names:        make(map[string][]string),
+		indexedNames: make(map[string]string),
 	}
 }
 
 // Register 注册插件
+// 重复注册同一ID时会按插件当前的能力列表和名称重建路由与名称索引
 func (pr *PluginRegistry) Register(plugin *PluginInfo) {
 	pr.mutex.Lock()
 	defer pr.mutex.Unlock()
+	pr.removeCapabilitiesLocked(plugin.ID)
+	pr.removeNameLocked(plugin.ID)
 	pr.plugins[plugin.ID] = plugin
+	caps := append([]string(nil), plugin.Capabilities...)
+	for _, capability := range caps {
+		pr.capabilities[capability] = append(pr.capabilities[capability], plugin.ID)
+	}
+	pr.indexedCaps[plugin.ID] = caps
+	if plugin.Name != "" {
+		pr.names[plugin.Name] = append(pr.names[plugin.Name], plugin.ID)
+		pr.indexedNames[plugin.ID] = plugin.Name
+	}
 }
 
 // Unregister 注销插件
 func (pr *PluginRegistry) Unregister(pluginID string) {
 	pr.mutex.Lock()
 	defer pr.mutex.Unlock()
+	pr.removeCapabilitiesLocked(pluginID)
+	pr.removeNameLocked(pluginID)
 	delete(pr.plugins, pluginID)
 }
 
@@ -227,9 +613,133 @@ func (pr *PluginRegistry) List() []*PluginInfo {
 	return plugins
 }
 
+// Walk 在持有读锁期间依次将每个插件传给 fn，fn 返回false时停止遍历
+// 遍历期间注册表不会变化，适合需要一致读取多个插件的场景；
+// fn 不能调用注册表的写方法（Register/Unregister）且应尽快返回，耗时操作应先收集插件再在遍历外执行
+func (pr *PluginRegistry) Walk(fn func(plugin *PluginInfo) bool) {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	for _, plugin := range pr.plugins {
+		if !fn(plugin) {
+			return
+		}
+	}
+}
+
+// UpdateStatus 在写锁保护下更新插件状态
+// 返回值：更新前的状态，插件是否存在；调用方可据此判断自己是否是唯一完成该状态转换的一方
+func (pr *PluginRegistry) UpdateStatus(pluginID string, status PluginStatus) (old PluginStatus, ok bool) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+
+	plugin, exists := pr.plugins[pluginID]
+	if !exists {
+		return "", false
+	}
+	old = plugin.Status
+	plugin.Status = status
+	return old, true
+}
+
+// GetStatus 在读锁保护下获取插件状态，与 UpdateStatus 配对使用
+func (pr *PluginRegistry) GetStatus(pluginID string) (PluginStatus, bool) {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	plugin, exists := pr.plugins[pluginID]
+	if !exists {
+		return "", false
+	}
+	return plugin.Status, true
+}
+
 // Count 获取插件数量
 func (pr *PluginRegistry) Count() int {
 	pr.mutex.RLock()
 	defer pr.mutex.RUnlock()
 	return len(pr.plugins)
 }
+
+// FindByCapability 获取提供指定能力的所有插件（按注册顺序）
+func (pr *PluginRegistry) FindByCapability(capability string) []*PluginInfo {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	ids := pr.capabilities[capability]
+	plugins := make([]*PluginInfo, 0, len(ids))
+	for _, id := range ids {
+		if plugin, exists := pr.plugins[id]; exists {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// GetByName 获取指定名称的所有插件（按注册顺序）
+// 通过名称索引查找，不遍历插件；同一插件的多个实例名称相同、ID不同
+func (pr *PluginRegistry) GetByName(name string) []*PluginInfo {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	ids := pr.names[name]
+	plugins := make([]*PluginInfo, 0, len(ids))
+	for _, id := range ids {
+		if plugin, exists := pr.plugins[id]; exists {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// CapabilityMap 获取路由表的副本：能力 -> 提供该能力的插件ID列表（按注册顺序）
+func (pr *PluginRegistry) CapabilityMap() map[string][]string {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	result := make(map[string][]string, len(pr.capabilities))
+	for capability, ids := range pr.capabilities {
+		result[capability] = append([]string(nil), ids...)
+	}
+	return result
+}
+
+// removeCapabilitiesLocked 从路由表中移除插件的所有能力（调用方需持有写锁）
+func (pr *PluginRegistry) removeCapabilitiesLocked(pluginID string) {
+	for _, capability := range pr.indexedCaps[pluginID] {
+		ids := pr.capabilities[capability]
+		for i, id := range ids {
+			if id == pluginID {
+				ids = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(pr.capabilities, capability)
+		} else {
+			pr.capabilities[capability] = ids
+		}
+	}
+	delete(pr.indexedCaps, pluginID)
+}
+
+// removeNameLocked 从名称索引中移除插件（调用方需持有写锁）
+func (pr *PluginRegistry) removeNameLocked(pluginID string) {
+	name, exists := pr.indexedNames[pluginID]
+	if !exists {
+		return
+	}
+	ids := pr.names[name]
+	for i, id := range ids {
+		if id == pluginID {
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(pr.names, name)
+	} else {
+		pr.names[name] = ids
+	}
+	delete(pr.indexedNames, pluginID)
+}
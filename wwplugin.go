@@ -42,8 +42,8 @@
 //
 // 插件间调用:
 //
-//	// 在插件中调用其他插件的函数
-//	resp, err := plugin.CallOtherPlugin("targetPluginID", "FunctionName", params)
+//	// 在插件函数中调用其他插件的函数，传入函数收到的 ctx
+//	resp, err := plugin.CallOtherPluginCtx(ctx, "targetPluginID", "FunctionName", params)
 package wwplugin
 
 // Version 库版本号